@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -19,6 +21,21 @@ type App struct {
 
 	Platforms PlatformMap
 	Versions  []Version
+
+	// Archs opts in to architectures that wildcards do not expand to by default,
+	// e.g. riscv64 and ppc64le on linux.
+	Archs []Arch
+
+	// Checksum is a path template of a file containing SHA-256 checksum of the artifact.
+	// It is relative to the target as Path is.
+	// Install scripts verify the artifact with it if it is given.
+	Checksum string
+
+	// Cache overrides the cache config of the app.
+	Cache CacheConfig
+
+	// Fallback overrides the fallback config of the app.
+	Fallback PlatformMap
 }
 
 var templateFuncs = template.FuncMap{
@@ -74,6 +91,19 @@ func ReadAppFromFs(fs fs.FS, p string) (App, error) {
 	return app, nil
 }
 
+// FindVersion returns the version line that the given version or alias belongs to.
+func (r App) FindVersion(v string) (Version, bool) {
+	for _, version := range r.Versions {
+		for value := range version.Values() {
+			if value == v {
+				return version, true
+			}
+		}
+	}
+
+	return "", false
+}
+
 func (r App) Build(v Item) (string, error) {
 	tmpl := template.New("")
 	tmpl = tmpl.Funcs(templateFuncs)
@@ -89,3 +119,67 @@ func (r App) Build(v Item) (string, error) {
 
 	return buff.String(), nil
 }
+
+// Conflicts reports ambiguities within the app.
+// It reports platform patterns that match the same platform but map to different platforms,
+// variants unknown to the architecture,
+// aliases given on multiple version lines, and aliases equal to the version of another line.
+func (r App) Conflicts(t PlatformTable) []string {
+	vs := []string{}
+
+	// Platform <- Pattern
+	patterns := map[Platform]Platform{}
+	for _, pattern := range slices.Sorted(maps.Keys(r.Platforms)) {
+		for p := range t.Expand(pattern, r.Archs...) {
+			p = t.Normalize(p)
+			os, arch, variant := p.Split()
+			if variant != "" && !slices.Contains(arch.Variants(), variant) {
+				msg := fmt.Sprintf("platform %q has variant %q unknown to %s", pattern, variant, arch)
+				if !slices.Contains(vs, msg) {
+					vs = append(vs, msg)
+				}
+			}
+			if variant != "" && variant == arch.DefaultVariant() {
+				// e.g. "linux/arm64/v8" is same as "linux/arm64".
+				p = Platform(string(os) + "/" + string(arch))
+			}
+
+			first, ok := patterns[p]
+			if !ok {
+				patterns[p] = pattern
+				continue
+			}
+			if first == pattern || r.Platforms[first] == r.Platforms[pattern] {
+				continue
+			}
+
+			msg := fmt.Sprintf("platforms %q and %q both match %s but map to %q and %q", first, pattern, p, r.Platforms[first], r.Platforms[pattern])
+			if !slices.Contains(vs, msg) {
+				vs = append(vs, msg)
+			}
+		}
+	}
+
+	// Version -> Line
+	versions := map[string]Version{}
+	for _, version := range r.Versions {
+		versions[version.Value()] = version
+	}
+
+	// Alias -> Line
+	aliases := map[string]Version{}
+	for _, version := range r.Versions {
+		for alias := range version.Aliases() {
+			if line, ok := versions[alias]; ok && line != version {
+				vs = append(vs, fmt.Sprintf("alias %q of versions line %q is the version of line %q", alias, string(version), string(line)))
+			}
+			if line, ok := aliases[alias]; ok && line != version {
+				vs = append(vs, fmt.Sprintf("alias %q is given on versions lines %q and %q", alias, string(line), string(version)))
+				continue
+			}
+			aliases[alias] = version
+		}
+	}
+
+	return vs
+}
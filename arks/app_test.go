@@ -0,0 +1,72 @@
+package arks_test
+
+import (
+	"testing"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppConflicts(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		app := arks.App{
+			Platforms: arks.PlatformMap{
+				"linux/_amd64/": "linux/amd64/",
+				"linux/_arm64/": "linux/arm64/",
+			},
+			Versions: []arks.Version{"1.0.0 1.0", "1.1.0 1.1 1 latest"},
+		}
+		require.Empty(t, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+	t.Run("overlapping platforms", func(t *testing.T) {
+		app := arks.App{
+			Platforms: arks.PlatformMap{
+				"linux/_64/":    "linux/x64/",
+				"linux/_amd64/": "linux/amd64/",
+			},
+		}
+		require.Equal(t, []string{
+			`platforms "linux/_64/" and "linux/_amd64/" both match linux/amd64 but map to "linux/x64/" and "linux/amd64/"`,
+		}, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+	t.Run("overlapping platforms with same target", func(t *testing.T) {
+		app := arks.App{
+			Platforms: arks.PlatformMap{
+				"linux/_64/":    "linux/amd64/",
+				"linux/_amd64/": "linux/amd64/",
+			},
+		}
+		require.Empty(t, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+	t.Run("alias on multiple lines", func(t *testing.T) {
+		app := arks.App{
+			Versions: []arks.Version{"1.0.0 latest", "1.1.0 latest"},
+		}
+		require.Equal(t, []string{
+			`alias "latest" is given on versions lines "1.0.0 latest" and "1.1.0 latest"`,
+		}, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+	t.Run("alias equal to version", func(t *testing.T) {
+		app := arks.App{
+			Versions: []arks.Version{"1.0.0", "1.1.0 1.0.0"},
+		}
+		require.Equal(t, []string{
+			`alias "1.0.0" of versions line "1.1.0 1.0.0" is the version of line "1.0.0"`,
+		}, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+	t.Run("variants", func(t *testing.T) {
+		app := arks.App{
+			Platforms: arks.PlatformMap{
+				"linux/arm/v6/":   "linux/armv6/",
+				"linux/arm/v7/":   "linux/armv7/",
+				"linux/arm64/":    "linux/arm64/",
+				"linux/arm64/v8/": "linux/aarch64/",
+				"linux/amd64/v7/": "linux/amd64/",
+			},
+		}
+		require.Equal(t, []string{
+			`platform "linux/amd64/v7/" has variant "v7" unknown to amd64`,
+			`platforms "linux/arm64/" and "linux/arm64/v8/" both match linux/arm64 but map to "linux/arm64/" and "linux/aarch64/"`,
+		}, app.Conflicts(arks.DefaultPlatformTable()))
+	})
+}
@@ -0,0 +1,117 @@
+package arks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// AppTest is a golden test case of an app given in app_test.yaml next to app.yaml.
+type AppTest struct {
+	// Origin to resolve, either an item or URL of an item.
+	Origin string
+	// Target expected to be resolved.
+	Target string
+	// Missing is true if the origin is expected not to be found.
+	Missing bool
+}
+
+// ReadAppTestsFromFs reads the golden test cases of the app in the given directory.
+// It returns no test cases if there is no app_test.yaml.
+func ReadAppTestsFromFs(fs fs.FS, p string) ([]AppTest, error) {
+	f, err := fs.Open(filepath.Join(p, "app_test.yaml"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	vs := []AppTest{}
+	if err := yaml.NewDecoder(f).Decode(&vs); err != nil {
+		return nil, fmt.Errorf("decode app tests: %w", err)
+	}
+
+	return vs, nil
+}
+
+// Key returns the origin of the test case as it is rendered.
+func (t AppTest) Key() (string, error) {
+	p := t.Origin
+	if strings.Contains(p, "://") {
+		u, err := url.Parse(p)
+		if err != nil {
+			return "", fmt.Errorf("parse URL: %w", err)
+		}
+		p = u.Path
+	}
+
+	return strings.TrimLeft(p, "/"), nil
+}
+
+// RunAppTests runs the given test cases against both the items built from the app
+// and the results of the querier.
+// It returns a description of each failure.
+func RunAppTests(ctx context.Context, q Querier, c Config, app App, tests []AppTest) ([]string, error) {
+	build, err := c.Build(app)
+	if err != nil {
+		return nil, fmt.Errorf("prepare build for app: %w", err)
+	}
+
+	// Origin -> Target
+	built := map[string]string{}
+	for items, err := range build {
+		if err != nil {
+			return nil, fmt.Errorf("build app: %w", err)
+		}
+		for _, item := range items {
+			built[strings.TrimLeft(item.Origin, "/")] = item.Target
+		}
+	}
+
+	vs := []string{}
+	for _, t := range tests {
+		k, err := t.Key()
+		if err != nil {
+			vs = append(vs, fmt.Sprintf("%s: %s", t.Origin, err))
+			continue
+		}
+
+		expected := t.Target
+		if t.Missing {
+			expected = "not found"
+		}
+
+		target, ok := built[k]
+		switch {
+		case !ok && !t.Missing:
+			vs = append(vs, fmt.Sprintf("%s: build: expected %s but not rendered", t.Origin, expected))
+		case ok && (t.Missing || target != t.Target):
+			vs = append(vs, fmt.Sprintf("%s: build: expected %s but got %s", t.Origin, expected, target))
+		}
+
+		v, err := ParseItem("/" + k)
+		if err != nil {
+			vs = append(vs, fmt.Sprintf("%s: query: parse item: %s", t.Origin, err))
+			continue
+		}
+
+		res, err := q.Query(ctx, v)
+		switch {
+		case err != nil && !(t.Missing && errors.Is(err, os.ErrNotExist)):
+			vs = append(vs, fmt.Sprintf("%s: query: expected %s but got error: %s", t.Origin, expected, err))
+		case err == nil && (t.Missing || res.Target != t.Target):
+			vs = append(vs, fmt.Sprintf("%s: query: expected %s but got %s", t.Origin, expected, res.Target))
+		}
+	}
+
+	return vs, nil
+}
@@ -0,0 +1,62 @@
+package arks_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAppTests(t *testing.T) {
+	port := testPort()
+	port["github.com/lesomnus/arrakis/arks/app_test.yaml"] = &fstest.MapFile{Data: []byte(`
+- origin: lesomnus/arrakis/arks@0.0.1/linux/x86_64
+  target: https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-amd64
+- origin: https://pkg.example.com/lesomnus/arrakis/arks@latest/linux/arm64
+  target: https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64
+- origin: lesomnus/arrakis/arks@0.0.3/linux/amd64
+  missing: true
+- origin: lesomnus/arrakis/arks@0.0.1/linux/arm64
+  target: https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64
+- origin: lesomnus/arrakis/arks@0.0.1/windows/amd64
+  target: https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-windows-amd64
+- origin: lesomnus/arrakis/arks@0.0.2/linux/amd64
+  missing: true
+`)}
+
+	p := "github.com/lesomnus/arrakis/arks"
+	tests, err := arks.ReadAppTestsFromFs(port, p)
+	require.NoError(t, err)
+	require.Len(t, tests, 6)
+
+	var (
+		c   arks.Config
+		app arks.App
+	)
+	err = arks.FsWalker{Fs: port}.Walk(arks.NewConfig(), ".", func(c_ arks.Config, p_ string, app_ arks.App) error {
+		if p_ == p {
+			c, app = c_, app_
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	failures, err := arks.RunAppTests(context.Background(), arks.FsQuerier{FS: port}, c, app, tests)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"lesomnus/arrakis/arks@0.0.1/linux/arm64: build: expected https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64 but got https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-arm64",
+		"lesomnus/arrakis/arks@0.0.1/linux/arm64: query: expected https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64 but got https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-arm64",
+		"lesomnus/arrakis/arks@0.0.1/windows/amd64: build: expected https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-windows-amd64 but not rendered",
+		`lesomnus/arrakis/arks@0.0.1/windows/amd64: query: expected https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-windows-amd64 but got error: platform "windows/amd64" is not supported by app "lesomnus/arrakis/arks"`,
+		"lesomnus/arrakis/arks@0.0.2/linux/amd64: build: expected not found but got https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64",
+		"lesomnus/arrakis/arks@0.0.2/linux/amd64: query: expected not found but got https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64",
+	}, failures)
+
+	t.Run("no tests", func(t *testing.T) {
+		tests, err := arks.ReadAppTestsFromFs(port, "github.com/protocolbuffers/protobuf/protoc")
+		require.NoError(t, err)
+		require.Empty(t, tests)
+	})
+}
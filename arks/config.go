@@ -6,11 +6,13 @@ import (
 	"io/fs"
 	"iter"
 	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 	"text/template"
+	"time"
 
 	"go.yaml.in/yaml/v4"
 )
@@ -18,10 +20,27 @@ import (
 type Config struct {
 	Path   string
 	Target TargetConfig
+	Cache  CacheConfig
+
+	// Platforms apps have no build for to ones that can run on them,
+	// e.g. "darwin/arm64" to "darwin/amd64".
+	Fallback PlatformMap
+	// Read only from the root config.
+	Oses map[Os]OsConfig
+	// Built from Oses.
+	Table PlatformTable `yaml:"-"`
 }
 
 func NewConfig() Config {
-	return Config{}
+	return Config{
+		Target: TargetConfig{
+			Scheme: "https",
+		},
+		Cache: CacheConfig{
+			Alias:  5 * time.Minute,
+			Pinned: 365 * 24 * time.Hour,
+		},
+	}
 }
 
 func ReadConfigFile(fs fs.FS, p string) (Config, error) {
@@ -60,10 +79,22 @@ func (c Config) Merge(other *Config) Config {
 	if other.Target.Suffix != "" {
 		c.Target.Suffix = other.Target.Suffix
 	}
+	if other.Target.Scheme != "" {
+		c.Target.Scheme = other.Target.Scheme
+	}
+	c.Cache = c.Cache.Merge(other.Cache)
+	c.Fallback = c.Fallback.Merge(other.Fallback)
 
 	return c
 }
 
+func (c Config) table() PlatformTable {
+	if c.Table == nil {
+		return DefaultPlatformTable()
+	}
+	return c.Table
+}
+
 func (Config) mergePath(a, b string) string {
 	if strings.HasPrefix(b, ".") {
 		a = filepath.Join(a, b)
@@ -84,6 +115,16 @@ func (c Config) Build(app App) (iter.Seq2[[]Item, error], error) {
 		return nil, fmt.Errorf("parse app path template: %w", err)
 	}
 
+	var tmpl_checksum *template.Template
+	if app.Checksum != "" {
+		tmpl_checksum = template.New("")
+		tmpl_checksum = tmpl_checksum.Funcs(templateFuncs)
+		tmpl_checksum, err = tmpl_checksum.Parse(app.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("parse app checksum template: %w", err)
+		}
+	}
+
 	return func(yield func([]Item, error) bool) {
 		if len(app.Versions) == 0 {
 			return
@@ -93,12 +134,46 @@ func (c Config) Build(app App) (iter.Seq2[[]Item, error], error) {
 			Path: c.Path,
 			Name: app.Name,
 		}
+		t := c.table()
+
+		// Pattern <- Requested platform
+		patterns := map[Platform]Platform{}
+		for _, pattern := range slices.Sorted(maps.Keys(app.Platforms)) {
+			for p := range t.Expand(pattern, app.Archs...) {
+				patterns[p] = pattern
+			}
+		}
+
+		// Fallback <- Requested platform
+		fallbacks := map[Platform]Platform{}
+		// Target <- []Requested platform
+		fallback_requests := map[Platform][]Platform{}
+		for _, k := range slices.Sorted(maps.Keys(c.Fallback)) {
+			for p := range t.Expand(k, app.Archs...) {
+				if _, ok := patterns[p]; ok {
+					continue
+				}
+				pattern, fallback, ok := c.fallback(app, p)
+				if !ok || fallback == "" {
+					continue
+				}
+
+				patterns[p] = pattern
+				fallbacks[p] = fallback
+				target := app.Platforms[pattern]
+				fallback_requests[target] = append(fallback_requests[target], p)
+			}
+		}
+
 		for _, version := range app.Versions {
 			v.Version = version
-			ps := app.Platforms.Expand()
+			ps := t.ExpandMap(app.Platforms, app.Archs...)
 			if len(ps) == 0 {
 				return
 			}
+			for target, requests := range fallback_requests {
+				ps[target] = append(ps[target], requests...)
+			}
 
 			targets := slices.Sorted(maps.Keys(ps))
 			buff := &strings.Builder{}
@@ -111,13 +186,33 @@ func (c Config) Build(app App) (iter.Seq2[[]Item, error], error) {
 					if !yield(nil, fmt.Errorf("execute app path template: %w", err)) {
 						return
 					}
+					continue
+				}
+				v.Target = c.Target.Scheme + "://" + c.Target.Path + c.Target.Suffix + buff.String()
+				if err := validateTarget(v.Target); err != nil {
+					if !yield(nil, err) {
+						return
+					}
+					continue
+				}
+				if tmpl_checksum != nil {
+					buff.Reset()
+					if err := tmpl_checksum.Execute(buff, v); err != nil {
+						if !yield(nil, fmt.Errorf("execute app checksum template: %w", err)) {
+							return
+						}
+						continue
+					}
+					v.Checksum = c.Target.Scheme + "://" + c.Target.Path + c.Target.Suffix + buff.String()
 				}
-				v.Target = c.Target.Path + c.Target.Suffix + buff.String()
 
-				for version := range version.Values() {
+				for value := range version.Values() {
 					vs := make([]Item, 0, len(requests))
 					for _, request := range requests {
-						v.Origin = c.Path + "/" + app.Name + "@" + version + "/" + string(request.Os()) + "/" + string(request.Arch())
+						v.Origin = origin(c.Path, app.Name, value, request)
+						v.Alias = value != version.Value()
+						v.Pattern = patterns[request]
+						v.Fallback = fallbacks[request]
 						vs = append(vs, v)
 					}
 
@@ -130,7 +225,92 @@ func (c Config) Build(app App) (iter.Seq2[[]Item, error], error) {
 	}, nil
 }
 
+// fallback returns the pattern matching the platform or the one it falls back to.
+func (c Config) fallback(app App, p Platform) (Platform, Platform, bool) {
+	t := c.table()
+	if pattern, ok := t.Match(app.Platforms, p, app.Archs...); ok {
+		return pattern, "", true
+	}
+
+	fallback, ok := t.Resolve(c.Fallback, p, app.Archs...)
+	if !ok || fallback == "" {
+		return "", "", false
+	}
+
+	pattern, ok := t.Match(app.Platforms, fallback, app.Archs...)
+	if !ok {
+		return "", "", false
+	}
+
+	return pattern, t.Normalize(fallback), true
+}
+
+// BuildVersion builds the items of the given version or alias.
+func (c Config) BuildVersion(app App, v string) ([]Item, error) {
+	version, ok := app.FindVersion(v)
+	if !ok {
+		return nil, &VersionNotFoundError{
+			App:      strings.TrimLeft(c.Path, "/") + "/" + app.Name,
+			Version:  v,
+			Versions: app.Versions,
+		}
+	}
+
+	app.Versions = []Version{version}
+	build, err := c.Build(app)
+	if err != nil {
+		return nil, fmt.Errorf("prepare build for app: %w", err)
+	}
+
+	prefix := c.Path + "/" + app.Name + "@" + v + "/"
+	vs := []Item{}
+	for items, err := range build {
+		if err != nil {
+			return nil, fmt.Errorf("build app: %w", err)
+		}
+		for _, item := range items {
+			if !strings.HasPrefix(item.Origin, prefix) {
+				continue
+			}
+			vs = append(vs, item)
+		}
+	}
+
+	return vs, nil
+}
+
+func validateTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid target: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid target %q: no scheme or host", target)
+	}
+
+	return nil
+}
+
 type TargetConfig struct {
 	Path   string
 	Suffix string
+	Scheme string
+}
+
+// CacheConfig is the max age of redirects.
+type CacheConfig struct {
+	// Of aliases, which can be moved to another version.
+	Alias  time.Duration
+	Pinned time.Duration
+}
+
+func (c CacheConfig) Merge(other CacheConfig) CacheConfig {
+	if other.Alias != 0 {
+		c.Alias = other.Alias
+	}
+	if other.Pinned != 0 {
+		c.Pinned = other.Pinned
+	}
+
+	return c
 }
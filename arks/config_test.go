@@ -0,0 +1,49 @@
+package arks_test
+
+import (
+	"testing"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	app := arks.App{
+		Name:      "foo",
+		Path:      "v{{.Version}}/foo-{{.Os}}-{{.Arch}}",
+		Platforms: arks.PlatformMap{"linux/amd64": "linux/amd64"},
+		Versions:  []arks.Version{"1.0.0"},
+	}
+
+	build := func(c arks.Config) (string, error) {
+		build, err := c.Build(app)
+		require.NoError(t, err)
+		for items, err := range build {
+			if err != nil {
+				return "", err
+			}
+			return items[0].Target, nil
+		}
+		return "", nil
+	}
+
+	t.Run("default scheme", func(t *testing.T) {
+		c := arks.NewConfig().Merge(&arks.Config{Path: "bar", Target: arks.TargetConfig{Path: "example.com/bar"}})
+		target, err := build(c)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/barv1.0.0/foo-linux-amd64", target)
+	})
+	t.Run("inherited scheme", func(t *testing.T) {
+		c := arks.NewConfig().
+			Merge(&arks.Config{Path: "bar", Target: arks.TargetConfig{Path: "example.com", Scheme: "http"}}).
+			Merge(&arks.Config{Path: "./baz", Target: arks.TargetConfig{Path: "./baz", Suffix: "/"}})
+		target, err := build(c)
+		require.NoError(t, err)
+		require.Equal(t, "http://example.com/baz/v1.0.0/foo-linux-amd64", target)
+	})
+	t.Run("target without host", func(t *testing.T) {
+		c := arks.NewConfig().Merge(&arks.Config{Path: "bar", Target: arks.TargetConfig{Path: "..", Suffix: "/"}})
+		_, err := build(c)
+		require.ErrorContains(t, err, "invalid target")
+	})
+}
@@ -0,0 +1,152 @@
+package arks
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// AppEntry is an app with the config it is built with.
+type AppEntry struct {
+	Config Config
+	App    App
+}
+
+// AppInfo describes an app in an [AppListing].
+type AppInfo struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// AppListing lists the apps under a path.
+type AppListing struct {
+	Apps []AppInfo `json:"apps"`
+}
+
+// VersionInfo describes a version in a [VersionListing].
+type VersionInfo struct {
+	Version string   `json:"version"`
+	Aliases []string `json:"aliases"`
+}
+
+// VersionListing lists the versions of an app.
+type VersionListing struct {
+	Path     string        `json:"path"`
+	Name     string        `json:"name"`
+	Versions []VersionInfo `json:"versions"`
+}
+
+// PlatformInfo describes a platform in a [PlatformListing].
+type PlatformInfo struct {
+	Platform Platform `json:"platform"`
+	Target   string   `json:"target"`
+	Checksum string   `json:"checksum,omitempty"`
+}
+
+// PlatformListing lists the platforms a version of an app supports.
+type PlatformListing struct {
+	Path      string         `json:"path"`
+	Name      string         `json:"name"`
+	Version   string         `json:"version"`
+	Aliases   []string       `json:"aliases"`
+	Platforms []PlatformInfo `json:"platforms"`
+}
+
+// NewAppListing lists the given apps under the given path in sorted order.
+func NewAppListing(apps []AppInfo, path string) AppListing {
+	path = strings.Trim(path, "/")
+
+	v := AppListing{Apps: []AppInfo{}}
+	for _, app := range apps {
+		if path != "" && app.Path != path && !strings.HasPrefix(app.Path, path+"/") {
+			continue
+		}
+		v.Apps = append(v.Apps, app)
+	}
+	slices.SortFunc(v.Apps, func(a, b AppInfo) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return v
+}
+
+func NewVersionListing(path string, name string, versions []Version) VersionListing {
+	v := VersionListing{
+		Path:     strings.Trim(path, "/"),
+		Name:     name,
+		Versions: make([]VersionInfo, 0, len(versions)),
+	}
+	for _, version := range versions {
+		v.Versions = append(v.Versions, newVersionInfo(version))
+	}
+
+	return v
+}
+
+// NewPlatformListing lists the platforms of the given items.
+// Items must be rendered ones of the same app and version.
+func NewPlatformListing(t PlatformTable, items []Item) (PlatformListing, error) {
+	if len(items) == 0 {
+		return PlatformListing{}, fmt.Errorf("no items to list")
+	}
+
+	info := newVersionInfo(items[0].Version)
+	v := PlatformListing{
+		Path:      strings.Trim(items[0].Path, "/"),
+		Name:      items[0].Name,
+		Version:   info.Version,
+		Aliases:   info.Aliases,
+		Platforms: []PlatformInfo{},
+	}
+
+	platforms, vs, err := byPlatform(t, items)
+	if err != nil {
+		return v, err
+	}
+	for _, p := range platforms {
+		v.Platforms = append(v.Platforms, PlatformInfo{
+			Platform: p,
+			Target:   vs[p].Target,
+			Checksum: vs[p].Checksum,
+		})
+	}
+
+	return v, nil
+}
+
+func newVersionInfo(v Version) VersionInfo {
+	aliases := slices.Collect(v.Aliases())
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	return VersionInfo{
+		Version: v.Value(),
+		Aliases: aliases,
+	}
+}
+
+// byPlatform returns the given items by their normalized requested platform
+// along with the platforms in sorted order.
+// If there are multiple items of the same platform, the first one is taken.
+func byPlatform(t PlatformTable, items []Item) ([]Platform, map[Platform]Item, error) {
+	vs := map[Platform]Item{}
+	for _, item := range items {
+		request, err := ParseItem("/" + item.Origin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse origin %q: %w", item.Origin, err)
+		}
+
+		p := t.Normalize(request.Platform)
+		if _, ok := vs[p]; ok {
+			continue
+		}
+		vs[p] = item
+	}
+
+	return slices.Sorted(maps.Keys(vs)), vs, nil
+}
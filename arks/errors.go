@@ -0,0 +1,118 @@
+package arks
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// AppNotFoundError is returned by a [Querier] when there is no app for the requested item.
+// It is an [os.ErrNotExist].
+type AppNotFoundError struct {
+	// App is the requested app in form of "path/name".
+	App string
+	// Suggestions are known apps similar to the requested one.
+	Suggestions []string
+}
+
+func (e *AppNotFoundError) Error() string {
+	return fmt.Sprintf("app %q not found", e.App)
+}
+
+func (e *AppNotFoundError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// VersionNotFoundError is returned by a [Querier] when the app does not have the requested version.
+// It is an [os.ErrNotExist].
+type VersionNotFoundError struct {
+	App     string
+	Version string
+	// Versions are the versions the app has.
+	Versions []Version
+}
+
+func (e *VersionNotFoundError) Error() string {
+	return fmt.Sprintf("version %q of app %q not found", e.Version, e.App)
+}
+
+func (e *VersionNotFoundError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// PlatformNotSupportedError is returned by a [Querier] when the app does not support the requested platform.
+// It is an [os.ErrNotExist].
+type PlatformNotSupportedError struct {
+	App      string
+	Platform Platform
+	// Platforms are the platforms the app supports.
+	Platforms []Platform
+}
+
+func (e *PlatformNotSupportedError) Error() string {
+	if os, arch, _ := e.Platform.Split(); os == "" || arch == "" {
+		return fmt.Sprintf("platform is not specified for app %q", e.App)
+	}
+	return fmt.Sprintf("platform %q is not supported by app %q", e.Platform, e.App)
+}
+
+func (e *PlatformNotSupportedError) Is(target error) bool {
+	return target == os.ErrNotExist
+}
+
+// suggest returns the candidates that are likely to be a typo of the given value,
+// ordered from the closest one.
+func suggest(v string, candidates []string) []string {
+	type entry struct {
+		v string
+		d int
+	}
+
+	es := []entry{}
+	for _, c := range candidates {
+		d := distance(strings.ToLower(v), strings.ToLower(c))
+		if d > max(2, len(v)/4) {
+			continue
+		}
+
+		es = append(es, entry{c, d})
+	}
+	slices.SortStableFunc(es, func(a, b entry) int {
+		if a.d != b.d {
+			return a.d - b.d
+		}
+		return strings.Compare(a.v, b.v)
+	})
+	if len(es) > 3 {
+		es = es[:3]
+	}
+
+	vs := make([]string, 0, len(es))
+	for _, e := range es {
+		vs = append(vs, e.v)
+	}
+	return vs
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,100 @@
+package arks
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Explanation describes each step of resolving an item.
+type Explanation struct {
+	// Item as it is requested.
+	Item Item
+
+	// Configs merged in each directory from the root of the port to the app.
+	Configs []ConfigStep
+
+	// App the item belongs to and the config it is built with.
+	App    App
+	Config Config
+
+	// Scores of each platform pattern of the app for the requested platform.
+	// Patterns not matching the platform are scored 0.
+	Scores map[Platform]int
+	// Scores of each platform pattern of the app for the platform the requested one falls back to.
+	// It is nil if the item does not fall back.
+	FallbackScores map[Platform]int
+
+	// Version line the requested version belongs to.
+	Version Version
+
+	// Output of the path template of the app.
+	Output string
+
+	// Result of the resolution.
+	Result Result
+}
+
+// ConfigStep is a config merged in a directory.
+type ConfigStep struct {
+	Dir    string
+	Config Config
+}
+
+// Explain resolves the given item from the port tracing each step.
+// The explanation is filled as far as the resolution succeeds, so it is returned along with the error.
+func Explain(fs fs.ReadDirFS, v Item) (Explanation, error) {
+	x := Explanation{Item: v}
+
+	c, p, app, err := FsQuerier{FS: fs}.findApp(v)
+	if err != nil {
+		return x, err
+	}
+
+	x.App = app
+	x.Config = c
+
+	walker := FsWalker{Fs: fs}
+	c_ := NewConfig()
+	for _, d := range dirChain(p) {
+		c_, err = walker.Step(c_, d, nil)
+		if err != nil {
+			return x, fmt.Errorf("%s: %w", d, err)
+		}
+		x.Configs = append(x.Configs, ConfigStep{Dir: d, Config: c_})
+	}
+
+	x.Scores = c.table().Scores(app.Platforms, v.Platform, app.Archs...)
+	if version, ok := app.FindVersion(v.Version.String()); ok {
+		x.Version = version
+	}
+
+	res, err := resolve(c, app, v)
+	if err != nil {
+		return x, err
+	}
+
+	x.Result = res
+	if res.Fallback != "" {
+		x.FallbackScores = c.table().Scores(app.Platforms, res.Fallback, app.Archs...)
+	}
+	x.Output = strings.TrimPrefix(res.Target, c.Target.Scheme+"://"+c.Target.Path+c.Target.Suffix)
+	return x, nil
+}
+
+// dirChain returns the directories from the root to the given directory.
+func dirChain(p string) []string {
+	vs := []string{"."}
+	if p == "." {
+		return vs
+	}
+
+	d := ""
+	for _, e := range strings.Split(filepath.ToSlash(p), "/") {
+		d = filepath.Join(d, e)
+		vs = append(vs, d)
+	}
+
+	return vs
+}
@@ -0,0 +1,84 @@
+package arks_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	port := testPort()
+
+	t.Run("resolved", func(t *testing.T) {
+		x, err := arks.Explain(port, arks.Item{
+			Path:     "/lesomnus/arrakis",
+			Name:     "arks",
+			Version:  "latest",
+			Platform: "linux/x86_64",
+		})
+		require.NoError(t, err)
+
+		dirs := []string{}
+		for _, step := range x.Configs {
+			dirs = append(dirs, step.Dir)
+		}
+		require.Equal(t, []string{
+			".",
+			"github.com",
+			"github.com/lesomnus",
+			"github.com/lesomnus/arrakis",
+			"github.com/lesomnus/arrakis/arks",
+		}, dirs)
+		require.Equal(t, "github.com", x.Configs[1].Config.Target.Path)
+		require.Equal(t, "/releases/download/", x.Configs[1].Config.Target.Suffix)
+		require.Equal(t, "lesomnus/arrakis", x.Config.Path)
+
+		require.Equal(t, map[arks.Platform]int{
+			"linux/_amd64/": 396,
+			"linux/_arm64/": 0,
+		}, x.Scores)
+		require.Equal(t, arks.Version("0.0.2 latest"), x.Version)
+		require.True(t, x.Result.Alias)
+		require.Equal(t, arks.Platform("linux/_amd64/"), x.Result.Pattern)
+		require.Equal(t, "v0.0.2/arks-linux-amd64", x.Output)
+		require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64", x.Result.Target)
+	})
+	t.Run("platform not supported", func(t *testing.T) {
+		x, err := arks.Explain(port, arks.Item{
+			Path:     "/lesomnus/arrakis",
+			Name:     "arks",
+			Version:  "0.0.1",
+			Platform: "windows/x86_64",
+		})
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Len(t, x.Configs, 5)
+		require.Equal(t, arks.Version("0.0.1"), x.Version)
+		require.Equal(t, map[arks.Platform]int{
+			"linux/_amd64/": 0,
+			"linux/_arm64/": 0,
+		}, x.Scores)
+		require.Empty(t, x.Result.Target)
+	})
+	t.Run("fallback", func(t *testing.T) {
+		x, err := arks.Explain(port, arks.Item{
+			Path:     "/example.com",
+			Name:     "fallback",
+			Version:  "1.0",
+			Platform: "darwin/arm64",
+		})
+		require.NoError(t, err)
+		require.Equal(t, arks.Platform("darwin/amd64"), x.Result.Fallback)
+		require.Equal(t, map[arks.Platform]int{
+			"darwin/_amd64/": 0,
+			"linux/_32/":     0,
+			"linux/_arm64/":  0,
+		}, x.Scores)
+		require.Equal(t, map[arks.Platform]int{
+			"darwin/_amd64/": 412,
+			"linux/_32/":     0,
+			"linux/_arm64/":  0,
+		}, x.FallbackScores)
+	})
+}
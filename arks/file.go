@@ -29,6 +29,9 @@ func (w FsWalker) Step(c Config, p string, f FsWalkFunc) (Config, error) {
 	}
 
 	c_next := c.Merge(&c_)
+	if p == "." {
+		c_next.Table = DefaultPlatformTable().Merge(c_.Oses)
+	}
 
 	if app, err := ReadAppFromFs(w.Fs, p); err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
@@ -40,6 +43,8 @@ func (w FsWalker) Step(c Config, p string, f FsWalkFunc) (Config, error) {
 			c_.Path = c.Path
 			c_.Target.Path = c.Target.Path
 		}
+		c_.Cache = c_.Cache.Merge(app.Cache)
+		c_.Fallback = c_.Fallback.Merge(app.Fallback)
 
 		if err := f(c_, p, app); err != nil {
 			return Config{}, fmt.Errorf("visit app: %w", err)
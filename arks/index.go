@@ -0,0 +1,96 @@
+package arks
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// IndexQuerier answers queries from an origin to target map
+// built by walking the whole port once.
+// Items not in the map, such as ones with a platform spelled differently,
+// are resolved in the same way as [FsQuerier].
+type IndexQuerier struct {
+	index map[string]Result
+	// Apps by "path/name".
+	apps map[string]AppEntry
+	// Table the index is built with.
+	table PlatformTable
+}
+
+func NewIndexQuerier(fs fs.ReadDirFS) (*IndexQuerier, error) {
+	t, err := LoadPlatformTable(fs)
+	if err != nil {
+		return nil, fmt.Errorf("load platform table: %w", err)
+	}
+
+	index := map[string]Result{}
+	apps := map[string]AppEntry{}
+	err = FsWalker{Fs: fs}.Walk(NewConfig(), ".", func(c Config, p string, app App) error {
+		apps[strings.TrimLeft(c.Path, "/")+"/"+app.Name] = AppEntry{c, app}
+
+		build, err := c.Build(app)
+		if err != nil {
+			return fmt.Errorf("prepare build for app: %w", err)
+		}
+		for items, err := range build {
+			if err != nil {
+				return fmt.Errorf("build app: %w", err)
+			}
+			for _, item := range items {
+				index[item.Origin] = Result{Item: item, Cache: c.Cache}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndexQuerier{index: index, apps: apps, table: t}, nil
+}
+
+// Table returns the platform table the index is built with.
+func (q *IndexQuerier) Table() PlatformTable {
+	return q.table
+}
+
+// Len returns the number of origins in the index.
+func (q *IndexQuerier) Len() int {
+	return len(q.index)
+}
+
+func (q *IndexQuerier) Query(ctx context.Context, v Item) (Result, error) {
+	k := origin(strings.TrimLeft(v.Path, "/"), v.Name, v.Version.String(), v.Platform)
+	if res, ok := q.index[k]; ok {
+		return res, nil
+	}
+
+	c, app, err := q.QueryApp(ctx, v)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return resolve(c, app, v)
+}
+
+func (q *IndexQuerier) QueryApp(ctx context.Context, v Item) (Config, App, error) {
+	name := strings.TrimLeft(v.Path, "/") + "/" + v.Name
+	entry, ok := q.apps[name]
+	if !ok {
+		return Config{}, App{}, &AppNotFoundError{
+			App:         name,
+			Suggestions: suggest(name, slices.Collect(maps.Keys(q.apps))),
+		}
+	}
+
+	return entry.Config, entry.App, nil
+}
+
+func (q *IndexQuerier) ListApps(ctx context.Context) ([]AppEntry, error) {
+	return slices.Collect(maps.Values(q.apps)), nil
+}
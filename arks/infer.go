@@ -0,0 +1,82 @@
+package arks
+
+import (
+	"net/http"
+	"strings"
+)
+
+// inferPlatform fills the missing OS and architecture of the given platform from the request.
+// They are taken from "os" and "arch" query parameters, "X-Arks-Os" and "X-Arks-Arch" headers,
+// or the User-Agent header in that order.
+func inferPlatform(t PlatformTable, r *http.Request, p Platform) Platform {
+	os, arch, variant := p.Split()
+
+	q := r.URL.Query()
+	if os == "" {
+		os = Os(q.Get("os"))
+	}
+	if arch == "" {
+		arch = Arch(q.Get("arch"))
+	}
+	if os == "" {
+		os = Os(r.Header.Get("X-Arks-Os"))
+	}
+	if arch == "" {
+		arch = Arch(r.Header.Get("X-Arks-Arch"))
+	}
+	if os == "" || arch == "" {
+		os_, arch_ := parseUserAgent(r.UserAgent())
+		if os == "" {
+			os = os_
+		}
+		if arch == "" && os == os_ {
+			arch = arch_
+		}
+	}
+	if os == "" || arch == "" {
+		return p
+	}
+
+	return t.Normalize(Platform(string(os) + "/" + string(arch) + "/" + string(variant)).WithLibc(p.Libc()))
+}
+
+// parseUserAgent makes the best guess of the platform from the given User-Agent.
+// Clients such as curl, wget, and Go HTTP client do not tell their platform
+// so nothing can be guessed from them.
+// If only the OS is found, no architecture is returned.
+//
+// E.g.
+//
+//	"Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.22631; en-US) PowerShell/7.4.6"
+//	"Wget/1.21.4 (linux-gnu)"
+func parseUserAgent(ua string) (Os, Arch) {
+	ua = strings.ToLower(ua)
+
+	os := Os("")
+	switch {
+	case strings.Contains(ua, "windows"):
+		os = OsWindows
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"), strings.Contains(ua, "darwin"):
+		os = OsDarwin
+	case strings.Contains(ua, "android"):
+		return "", ""
+	case strings.Contains(ua, "linux"):
+		os = OsLinux
+	default:
+		return "", ""
+	}
+
+	arch := Arch("")
+	switch {
+	case strings.Contains(ua, "aarch64"), strings.Contains(ua, "arm64"):
+		arch = ArchArm64
+	case strings.Contains(ua, "x86_64"), strings.Contains(ua, "amd64"), strings.Contains(ua, "x64"), strings.Contains(ua, "win64"), strings.Contains(ua, "wow64"):
+		arch = ArchAmd64
+	case strings.Contains(ua, "i686"), strings.Contains(ua, "i386"), strings.Contains(ua, "x86"):
+		arch = ArchX86
+	case strings.Contains(ua, "armv"):
+		arch = ArchArm
+	}
+
+	return os, arch
+}
@@ -0,0 +1,129 @@
+package arks
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed install.sh.tpl
+	installShTpl string
+	//go:embed install.ps1.tpl
+	installPs1Tpl string
+
+	installFuncs = template.FuncMap{
+		// Quotes for POSIX shell.
+		"sh": func(v string) string {
+			return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+		},
+		// Quotes for PowerShell.
+		"ps": func(v string) string {
+			return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		},
+		// Escapes for the inside of a double-quoted PowerShell string.
+		"psq": func(v string) string {
+			return psEscaper.Replace(v)
+		},
+	}
+
+	installScripts = map[string]installScriptKind{
+		"install.sh": {
+			tmpl:   template.Must(template.New("install.sh").Funcs(installFuncs).Parse(installShTpl)),
+			accept: func(os Os) bool { return os != OsWindows },
+		},
+		"install.ps1": {
+			tmpl:   template.Must(template.New("install.ps1").Funcs(installFuncs).Parse(installPs1Tpl)),
+			accept: func(os Os) bool { return os == OsWindows },
+		},
+	}
+)
+
+// psEscaper escapes characters that are special in a double-quoted PowerShell string,
+// including typographic quotes PowerShell takes as double quotes.
+var psEscaper = strings.NewReplacer(
+	"`", "``",
+	"$", "`$",
+	`"`, "`\"",
+	"\u201c", "`\u201c",
+	"\u201d", "`\u201d",
+	"\u201e", "`\u201e",
+)
+
+type installScriptKind struct {
+	tmpl *template.Template
+	// Platforms of which OS the script can install on.
+	accept func(os Os) bool
+}
+
+type installScript struct {
+	Name    string
+	Version string
+	Bin     string
+
+	Targets []installTarget
+	// Space separated list of the platforms in Targets.
+	Platforms string
+}
+
+type installTarget struct {
+	Platform string
+	Arch     string
+	Target   string
+	Checksum string
+}
+
+// IsInstallScript reports whether the given name is a name of an install script,
+// which are "install.sh" and "install.ps1".
+func IsInstallScript(name string) bool {
+	_, ok := installScripts[name]
+	return ok
+}
+
+// WriteInstallScript writes the install script of the given name.
+// The script installs one of the given items that matches the platform it runs on.
+// Items must be rendered ones of the same app and version.
+func WriteInstallScript(w io.Writer, t PlatformTable, name string, items []Item) error {
+	kind, ok := installScripts[name]
+	if !ok {
+		return fmt.Errorf("unknown install script: %q", name)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no items to install")
+	}
+
+	v := installScript{
+		Name:    items[0].Name,
+		Version: items[0].Version.Value(),
+		Bin:     items[0].Name,
+	}
+	platforms, vs, err := byPlatform(t, items)
+	if err != nil {
+		return err
+	}
+
+	ps := []string{}
+	for _, p := range platforms {
+		if !kind.accept(p.Os()) {
+			continue
+		}
+
+		item := vs[p]
+		ps = append(ps, string(p))
+		v.Targets = append(v.Targets, installTarget{
+			Platform: string(p),
+			Arch:     string(p.Arch()),
+			Target:   item.Target,
+			Checksum: item.Checksum,
+		})
+	}
+	if len(v.Targets) == 0 {
+		return fmt.Errorf("no platforms for %s: %w", name, os.ErrNotExist)
+	}
+	v.Platforms = strings.Join(ps, " ")
+
+	return kind.tmpl.Execute(w, v)
+}
@@ -0,0 +1,69 @@
+# Installs {{.Name}} {{.Version}}.
+#
+# Usage: install.ps1 [-Dir DIR]
+#
+# The binary is placed in DIR, $env:ARKS_INSTALL_DIR, or $HOME\.local\bin.
+param(
+	[string]$Dir = $(if ($env:ARKS_INSTALL_DIR) { $env:ARKS_INSTALL_DIR } else { Join-Path $HOME '.local\bin' })
+)
+$ErrorActionPreference = 'Stop'
+
+$name = {{ps .Bin}} + '.exe'
+
+# 32-bit process on 64-bit Windows reports its own architecture.
+$arch = if ($env:PROCESSOR_ARCHITEW6432) { $env:PROCESSOR_ARCHITEW6432 } else { $env:PROCESSOR_ARCHITECTURE }
+$arch = switch ($arch) {
+	'AMD64' { 'amd64' }
+	'ARM64' { 'arm64' }
+	'x86' { 'x86' }
+	'ARM' { 'arm' }
+	default { $arch }
+}
+
+$targets = @{
+{{- range .Targets}}
+	{{ps .Arch}} = @({{ps .Target}}, {{ps .Checksum}})
+{{- end}}
+}
+if (-not $targets.ContainsKey($arch)) {
+	throw "{{psq .Bin}} {{psq .Version}} is not available for windows/$arch; available platforms: {{psq .Platforms}}"
+}
+$url, $sum = $targets[$arch]
+
+$tmp = Join-Path ([System.IO.Path]::GetTempPath()) ([System.IO.Path]::GetRandomFileName())
+New-Item -ItemType Directory -Path $tmp | Out-Null
+try {
+	$file = Join-Path $tmp $url.Split('/')[-1]
+	Invoke-WebRequest -UseBasicParsing -Uri $url -OutFile $file
+
+	if ($sum) {
+		$sum_file = Join-Path $tmp 'checksum'
+		Invoke-WebRequest -UseBasicParsing -Uri $sum -OutFile $sum_file
+		$want = ((Get-Content -Raw -Path $sum_file).Trim() -split '\s+')[0]
+		$got = (Get-FileHash -Algorithm SHA256 -Path $file).Hash
+		if ($want -ne $got) {
+			throw "checksum mismatch: expected $want but got $got"
+		}
+	}
+
+	$x = Join-Path $tmp 'x'
+	New-Item -ItemType Directory -Path $x | Out-Null
+	if ($file.EndsWith('.zip')) {
+		Expand-Archive -Path $file -DestinationPath $x
+	} elseif ($file.EndsWith('.tar.gz') -or $file.EndsWith('.tgz')) {
+		tar -xzf $file -C $x
+	} else {
+		Copy-Item -Path $file -Destination (Join-Path $x $name)
+	}
+
+	$bin = Get-ChildItem -Path $x -Recurse -File -Filter $name | Select-Object -First 1
+	if (-not $bin) {
+		throw "$name is not found in $($url.Split('/')[-1])"
+	}
+
+	New-Item -ItemType Directory -Force -Path $Dir | Out-Null
+	Copy-Item -Force -Path $bin.FullName -Destination (Join-Path $Dir $name)
+	Write-Host "$name {{psq .Version}} is installed at $(Join-Path $Dir $name)"
+} finally {
+	Remove-Item -Recurse -Force -Path $tmp
+}
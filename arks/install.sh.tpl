@@ -0,0 +1,78 @@
+#!/bin/sh
+# Installs {{.Name}} {{.Version}}.
+#
+# Usage: install.sh [DIR]
+#
+# The binary is placed in DIR, $ARKS_INSTALL_DIR, or $HOME/.local/bin.
+set -eu
+
+name={{sh .Bin}}
+version={{sh .Version}}
+platforms={{sh .Platforms}}
+dir="${1:-${ARKS_INSTALL_DIR:-$HOME/.local/bin}}"
+
+os=$(uname -s | tr '[:upper:]' '[:lower:]')
+arch=$(uname -m)
+case "$arch" in
+	x86_64 | amd64) arch=amd64 ;;
+	aarch64 | arm64) arch=arm64 ;;
+	i386 | i686 | x86) arch=x86 ;;
+	armv* | arm) arch=arm ;;
+esac
+
+case "$os/$arch" in
+{{- range .Targets}}
+	{{sh .Platform}}) url={{sh .Target}}; sum={{sh .Checksum}} ;;
+{{- end}}
+	*)
+		echo "$name $version is not available for $os/$arch" >&2
+		echo "available platforms: $platforms" >&2
+		exit 1
+		;;
+esac
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+fetch() {
+	if command -v curl >/dev/null 2>&1; then
+		curl -fsSL -o "$2" "$1"
+	else
+		wget -q -O "$2" "$1"
+	fi
+}
+
+file="$tmp/${url##*/}"
+fetch "$url" "$file"
+
+if [ -n "$sum" ]; then
+	fetch "$sum" "$tmp/checksum"
+	want=$(cut -d ' ' -f 1 <"$tmp/checksum")
+	if command -v sha256sum >/dev/null 2>&1; then
+		got=$(sha256sum "$file" | cut -d ' ' -f 1)
+	else
+		got=$(shasum -a 256 "$file" | cut -d ' ' -f 1)
+	fi
+	if [ "$want" != "$got" ]; then
+		echo "checksum mismatch: expected $want but got $got" >&2
+		exit 1
+	fi
+fi
+
+mkdir "$tmp/x"
+case "$file" in
+	*.zip) unzip -q "$file" -d "$tmp/x" ;;
+	*.tar.gz | *.tgz) tar -xzf "$file" -C "$tmp/x" ;;
+	*) cp "$file" "$tmp/x/$name" ;;
+esac
+
+bin=$(find "$tmp/x" -type f -name "$name" | head -n 1)
+if [ -z "$bin" ]; then
+	echo "$name is not found in ${url##*/}" >&2
+	exit 1
+fi
+
+mkdir -p "$dir"
+cp "$bin" "$dir/$name"
+chmod 0755 "$dir/$name"
+echo "$name $version is installed at $dir/$name"
@@ -11,8 +11,18 @@ type Item struct {
 	Version Version
 	Platform
 
+	// Pattern is the key of the platform map the platform of the origin matches.
+	Pattern Platform
+	// Fallback is the platform the target is built for
+	// if the app has no build for the platform of the origin and it falls back to.
+	Fallback Platform
+
 	Origin string
 	Target string
+	// Checksum is URL of a file containing SHA-256 checksum of the target if known.
+	Checksum string
+	// Alias is true if the origin refers the version by one of its aliases.
+	Alias bool
 }
 
 func ParseItem(s string) (Item, error) {
@@ -34,13 +44,14 @@ func ParseItem(s string) (Item, error) {
 	path := p[:i]   // /github.com/lesomnus/arrakis
 	name := p[i+1:] // arrk
 
-	version, platform_, ok := strings.Cut(v, "/")
-	if !ok {
-		return Item{}, errors.New("no platform found")
+	// Platform can be omitted.
+	version, platform_, _ := strings.Cut(v, "/")
+	if version == "" {
+		return Item{}, errors.New("no version found")
 	}
 
 	platform := Platform(platform_)
-	if os, arch, _ := platform.Split(); os == "" || arch == "" {
+	if os, arch, _ := platform.Split(); os == "" && arch != "" {
 		return Item{}, errors.New("invalid platform")
 	}
 
@@ -52,6 +63,21 @@ func ParseItem(s string) (Item, error) {
 	}, nil
 }
 
+// origin returns the key that the item with the given properties is rendered as.
+// The variant and the libc are included only if the platform has them.
+func origin(path string, name string, version string, p Platform) string {
+	os, arch, variant := p.Split()
+	v := path + "/" + name + "@" + version + "/" + string(os) + "/" + string(arch)
+	if variant := strings.Trim(string(variant), "/"); variant != "" {
+		v += "/" + variant
+	}
+	if libc := p.Libc(); libc != "" {
+		v += "/" + string(libc.Normalized())
+	}
+
+	return v
+}
+
 func (i Item) Os() string {
 	return string(i.Platform.Os())
 }
@@ -63,3 +89,11 @@ func (i Item) Arch() string {
 func (i Item) Variant() string {
 	return string(i.Platform.Variant())
 }
+
+// Libc returns the libc of the platform, or the default one of the OS if not given.
+func (i Item) Libc() string {
+	if libc := i.Platform.Libc(); libc != "" {
+		return string(libc.Normalized())
+	}
+	return string(DefaultPlatformTable().DefaultLibc(i.Platform.Os()))
+}
@@ -0,0 +1,402 @@
+package arks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Diagnostic is a problem found in a file of the port.
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Col     int    `json:"col"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Col, d.Message)
+}
+
+// In returns the diagnostic with its file joined to the given directory.
+func (d Diagnostic) In(dir string) Diagnostic {
+	d.File = filepath.Join(dir, d.File)
+	return d
+}
+
+// Lint validates every app.yaml, app_test.yaml, config.yaml and versions file in the port strictly.
+// Problems in the files are returned as diagnostics rather than an error,
+// so all of them can be reported at once.
+func Lint(fsys fs.ReadDirFS) ([]Diagnostic, error) {
+	t, err := LoadPlatformTable(fsys)
+	if err != nil {
+		// Problems of the root config are reported by the lint.
+		t = DefaultPlatformTable()
+	}
+
+	vs := []Diagnostic{}
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		var lint func(p string, r io.Reader) ([]Diagnostic, error)
+		switch d.Name() {
+		case "config.yaml":
+			lint = func(p string, r io.Reader) ([]Diagnostic, error) { return lintConfig(t, p, r) }
+		case "app.yaml":
+			lint = func(p string, r io.Reader) ([]Diagnostic, error) { return lintApp(t, p, r) }
+		case "versions":
+			lint = lintVersions
+		case "app_test.yaml":
+			lint = lintAppTests
+		default:
+			return nil
+		}
+
+		f, err := fsys.Open(p)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", p, err)
+		}
+		defer f.Close()
+
+		ds, err := lint(p, f)
+		if err != nil {
+			return fmt.Errorf("lint %s: %w", p, err)
+		}
+
+		vs = append(vs, ds...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+func lintConfig(t PlatformTable, p string, r io.Reader) ([]Diagnostic, error) {
+	n, vs, err := lintYaml(p, r, reflect.TypeFor[Config]())
+	if err != nil || n == nil {
+		return vs, err
+	}
+
+	for k, v := range yamlMapping(n) {
+		switch k.Value {
+		case "oses":
+			if p != "config.yaml" {
+				vs = append(vs, Diagnostic{p, k.Line, k.Column, "oses is read only from the root config"})
+			}
+		case "fallback":
+			vs = append(vs, lintPatterns(t, p, v)...)
+		}
+	}
+
+	return vs, nil
+}
+
+func lintApp(t PlatformTable, p string, r io.Reader) ([]Diagnostic, error) {
+	n, vs, err := lintYaml(p, r, reflect.TypeFor[App]())
+	if err != nil || n == nil {
+		return vs, err
+	}
+
+	for k, v := range yamlMapping(n) {
+		switch k.Value {
+		case "path", "checksum":
+			if v.Kind != yaml.ScalarNode {
+				continue
+			}
+			for _, msg := range lintTemplate(v.Value) {
+				vs = append(vs, Diagnostic{p, v.Line, v.Column, fmt.Sprintf("%s: %s", k.Value, msg)})
+			}
+
+		case "platforms", "fallback":
+			vs = append(vs, lintPatterns(t, p, v)...)
+		}
+	}
+
+	return vs, nil
+}
+
+// lintPatterns reports platform patterns of the given mapping that expand to no platforms.
+func lintPatterns(t PlatformTable, p string, n *yaml.Node) []Diagnostic {
+	vs := []Diagnostic{}
+	for k := range yamlMapping(n) {
+		if len(slices.Collect(t.Expand(Platform(k.Value)))) > 0 {
+			continue
+		}
+		vs = append(vs, Diagnostic{p, k.Line, k.Column, fmt.Sprintf("platform pattern %q expands to no platforms", k.Value)})
+	}
+	return vs
+}
+
+func lintAppTests(p string, r io.Reader) ([]Diagnostic, error) {
+	_, vs, err := lintYaml(p, r, reflect.TypeFor[[]AppTest]())
+	return vs, err
+}
+
+func lintVersions(p string, r io.Reader) ([]Diagnostic, error) {
+	vs := []Diagnostic{}
+
+	// Version -> Line
+	versions := map[string]int{}
+
+	i := 0
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		i++
+		l := s.Text()
+		v := strings.TrimSpace(l)
+		if v == "" || v[0] == '#' {
+			continue
+		}
+
+		version := Version(v).Value()
+		if j, ok := versions[version]; ok {
+			col := strings.Index(l, version) + 1
+			vs = append(vs, Diagnostic{p, i, col, fmt.Sprintf("duplicate version %q, first given at line %d", version, j)})
+			continue
+		}
+		versions[version] = i
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+// lintYaml parses the given YAML document and validates its keys and values against the given type.
+// It returns the root mapping node, or nil if the document is empty, not parsable or not a mapping.
+func lintYaml(p string, r io.Reader, t reflect.Type) (*yaml.Node, []Diagnostic, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var n yaml.Node
+	if err := yaml.Unmarshal(data, &n); err != nil {
+		line, col, msg := yamlErrorPos(err)
+		return nil, []Diagnostic{{p, line, col, msg}}, nil
+	}
+	if len(n.Content) == 0 {
+		return nil, nil, nil
+	}
+
+	root := n.Content[0]
+	vs := lintYamlNode(p, root, t)
+	if root.Kind != yaml.MappingNode {
+		return nil, vs, nil
+	}
+
+	return root, vs, nil
+}
+
+// lintYamlNode reports keys unknown to the given type and values not decodable into it.
+func lintYamlNode(p string, n *yaml.Node, t reflect.Type) []Diagnostic {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct && n.Kind == yaml.SequenceNode {
+		vs := []Diagnostic{}
+		for _, n := range n.Content {
+			vs = append(vs, lintYamlNode(p, n, t.Elem())...)
+		}
+		return vs
+	}
+	if t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && n.Kind == yaml.MappingNode {
+		vs := []Diagnostic{}
+		for k, v := range yamlMapping(n) {
+			vs = append(vs, lintYamlNode(p, k, t.Key())...)
+			vs = append(vs, lintYamlNode(p, v, t.Elem())...)
+		}
+		return vs
+	}
+	if t.Kind() != reflect.Struct {
+		v := reflect.New(t)
+		if err := n.Decode(v.Interface()); err != nil {
+			_, _, msg := yamlErrorPos(err)
+			return []Diagnostic{{p, n.Line, n.Column, msg}}
+		}
+		return nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return []Diagnostic{{p, n.Line, n.Column, "expected a mapping"}}
+	}
+
+	vs := []Diagnostic{}
+	for k, v := range yamlMapping(n) {
+		f, ok := yamlField(t, k.Value)
+		if !ok {
+			vs = append(vs, Diagnostic{p, k.Line, k.Column, fmt.Sprintf("unknown key %q", k.Value)})
+			continue
+		}
+
+		vs = append(vs, lintYamlNode(p, v, f.Type)...)
+	}
+
+	return vs
+}
+
+// yamlMapping iterates over the keys and values of the given mapping node.
+func yamlMapping(n *yaml.Node) func(yield func(k *yaml.Node, v *yaml.Node) bool) {
+	return func(yield func(k *yaml.Node, v *yaml.Node) bool) {
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if !yield(n.Content[i], n.Content[i+1]) {
+				return
+			}
+		}
+	}
+}
+
+// yamlField returns the field of the given struct type that the given key is decoded into.
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+var yamlErrorPattern = regexp.MustCompile(`line (\d+)(?:, column (\d+))?: (.*)`)
+
+// yamlErrorPos extracts the position and the message from the given YAML error.
+func yamlErrorPos(err error) (line int, col int, msg string) {
+	s := err.Error()
+	m := yamlErrorPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 1, 1, strings.TrimPrefix(s, "yaml: ")
+	}
+
+	line, _ = strconv.Atoi(m[1])
+	col, _ = strconv.Atoi(m[2])
+	if col == 0 {
+		col = 1
+	}
+	return line, col, m[3]
+}
+
+// lintTemplate reports problems of the given path template.
+func lintTemplate(s string) []string {
+	tmpl, err := template.New("").Funcs(templateFuncs).Parse(s)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if tmpl.Tree == nil {
+		return nil
+	}
+
+	vs := lintTemplateNode(tmpl.Tree.Root, reflect.TypeFor[Item]())
+	if len(vs) > 0 {
+		return vs
+	}
+
+	sample := Item{
+		Path:     "/example.com",
+		Name:     "app",
+		Version:  "1.2.3 1.2 latest",
+		Platform: "linux/amd64",
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return []string{err.Error()}
+	}
+
+	return nil
+}
+
+// lintTemplateNode reports fields the given template node references but the given type does not have.
+// Nodes the dot is not known for, such as bodies of range and with, are skipped.
+func lintTemplateNode(n parse.Node, t reflect.Type) []string {
+	vs := []string{}
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			break
+		}
+		for _, n := range n.Nodes {
+			vs = append(vs, lintTemplateNode(n, t)...)
+		}
+	case *parse.ActionNode:
+		vs = append(vs, lintTemplateNode(n.Pipe, t)...)
+	case *parse.PipeNode:
+		if n == nil {
+			break
+		}
+		for _, c := range n.Cmds {
+			vs = append(vs, lintTemplateNode(c, t)...)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			vs = append(vs, lintTemplateNode(a, t)...)
+		}
+	case *parse.IfNode:
+		vs = append(vs, lintTemplateNode(n.Pipe, t)...)
+		vs = append(vs, lintTemplateNode(n.List, t)...)
+		vs = append(vs, lintTemplateNode(n.ElseList, t)...)
+	case *parse.RangeNode:
+		vs = append(vs, lintTemplateNode(n.Pipe, t)...)
+	case *parse.WithNode:
+		vs = append(vs, lintTemplateNode(n.Pipe, t)...)
+	case *parse.FieldNode:
+		if err := lintTemplateField(t, n.Ident); err != nil {
+			vs = append(vs, err.Error())
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			if err := lintTemplateField(t, n.Ident[1:]); err != nil {
+				vs = append(vs, err.Error())
+			}
+		}
+	}
+
+	return vs
+}
+
+// lintTemplateField reports an error if the given chain of fields can not be evaluated on the given type.
+func lintTemplateField(t reflect.Type, ident []string) error {
+	for i, name := range ident {
+		if m, ok := t.MethodByName(name); ok {
+			if m.Type.NumOut() == 0 {
+				return fmt.Errorf("method %s of type %s returns nothing", name, t)
+			}
+			t = m.Type.Out(0)
+			continue
+		}
+		if t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok && f.IsExported() {
+				t = f.Type
+				continue
+			}
+		}
+
+		return fmt.Errorf("field %q not found in type %s", "."+strings.Join(ident[:i+1], "."), t)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package arks_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		ds, err := arks.Lint(testPort())
+		require.NoError(t, err)
+		require.Empty(t, ds)
+	})
+	t.Run("problems", func(t *testing.T) {
+		port := fstest.MapFS{
+			"foo/config.yaml": &fstest.MapFile{Data: []byte(`path: ".."
+target:
+  sufix: /releases/
+cache:
+  alias: soon
+oses:
+  linux:
+    arhcs: {}
+fallback:
+  darwin/_bar/: darwin/amd64
+`)},
+			"foo/bar/app.yaml": &fstest.MapFile{Data: []byte(`path: v{{.Version}}/{{.Nmae}}-{{.Os}}
+checksum: "{{.Version"
+platfroms:
+  linux/_amd64/: linux/amd64/
+platforms:
+  linux/_amd64/: linux/amd64/
+  linux/_foo/: linux/foo/
+`)},
+			"foo/bar/versions": &fstest.MapFile{Data: []byte(`
+1.0.0
+1.0.1 latest
+  1.0.0 1.0
+`)},
+			"foo/baz/app.yaml": &fstest.MapFile{Data: []byte("path: [\n")},
+		}
+
+		ds, err := arks.Lint(port)
+		require.NoError(t, err)
+
+		type pos struct {
+			File string
+			Line int
+			Col  int
+		}
+		vs := []pos{}
+		for _, d := range ds {
+			vs = append(vs, pos{d.File, d.Line, d.Col})
+		}
+		require.ElementsMatch(t, []pos{
+			{"foo/config.yaml", 3, 3},
+			{"foo/config.yaml", 5, 10},
+			{"foo/config.yaml", 6, 1},
+			{"foo/config.yaml", 8, 5},
+			{"foo/config.yaml", 10, 3},
+			{"foo/bar/app.yaml", 1, 7},
+			{"foo/bar/app.yaml", 2, 11},
+			{"foo/bar/app.yaml", 3, 1},
+			{"foo/bar/app.yaml", 7, 3},
+			{"foo/bar/versions", 4, 3},
+			{"foo/baz/app.yaml", 1, 1},
+		}, vs)
+
+		for _, d := range ds {
+			if d.File == "foo/bar/app.yaml" && d.Line == 1 {
+				require.Contains(t, d.Message, ".Nmae")
+			}
+		}
+	})
+}
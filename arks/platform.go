@@ -2,6 +2,7 @@ package arks
 
 import (
 	"iter"
+	"maps"
 	"slices"
 	"strings"
 )
@@ -9,6 +10,7 @@ import (
 type Os string
 type Arch string
 type Variant string
+type Libc string
 
 // Canonical? OS and Arch names.
 // See:
@@ -18,10 +20,20 @@ const (
 	OsLinux   Os = "linux"
 	OsWindows Os = "windows"
 	OsDarwin  Os = "darwin"
-
-	ArchArm   Arch = "arm"
-	ArchArm64 Arch = "arm64"
-	ArchAmd64 Arch = "amd64"
+	OsFreeBSD Os = "freebsd"
+	OsOpenBSD Os = "openbsd"
+	OsNetBSD  Os = "netbsd"
+	OsAndroid Os = "android"
+	OsIllumos Os = "illumos"
+
+	ArchArm      Arch = "arm"
+	ArchArm64    Arch = "arm64"
+	ArchAmd64    Arch = "amd64"
+	ArchRiscv64  Arch = "riscv64"
+	ArchPpc64le  Arch = "ppc64le"
+	ArchS390x    Arch = "s390x"
+	ArchLoong64  Arch = "loong64"
+	ArchMips64le Arch = "mips64le"
 
 	ArchX86     Arch = "x86"
 	ArchX86_64  Arch = "x86_64"
@@ -31,8 +43,12 @@ const (
 	VariantArmV6 Variant = "v6"
 	VariantArmV7 Variant = "v7"
 	VariantArmV8 Variant = "v8"
+
+	LibcGlibc Libc = "glibc"
+	LibcMusl  Libc = "musl"
 )
 
+// Platform in form of "OS/ARCH/VARIANT/LIBC" where the variant and the libc are optional.
 type Platform string
 
 func (p Platform) Split() (os Os, arch Arch, variant Variant) {
@@ -42,11 +58,24 @@ func (p Platform) Split() (os Os, arch Arch, variant Variant) {
 		arch = Arch(es[1])
 	}
 	if len(es) > 2 {
-		variant = Variant(es[2])
+		v, _ := cutLibc(es[2])
+		variant = Variant(v)
 	}
 	return
 }
 
+// cutLibc splits the segments after the architecture into the variant and the libc.
+func cutLibc(s string) (string, Libc) {
+	s_ := strings.TrimRight(s, "/")
+	i := strings.LastIndex(s_, "/")
+	libc := Libc(s_[i+1:])
+	if libc != "_" && !slices.Contains([]Libc{LibcGlibc, LibcMusl}, libc.Normalized()) {
+		return s, ""
+	}
+
+	return s_[:max(i, 0)], libc
+}
+
 func (p Platform) Os() Os {
 	os, _, _ := p.Split()
 	return os
@@ -62,42 +91,68 @@ func (p Platform) Variant() Variant {
 	return variant
 }
 
-func (p Platform) Normalized() Platform {
+func (p Platform) Libc() Libc {
+	es := strings.SplitN(string(p), "/", 3)
+	if len(es) < 3 {
+		return ""
+	}
+
+	_, libc := cutLibc(es[2])
+	return libc
+}
+
+func (p Platform) WithLibc(libc Libc) Platform {
+	if libc == "" || p.Libc() != "" {
+		return p
+	}
+	if os, arch, _ := p.Split(); os == "" || arch == "" {
+		return p
+	}
+
+	return Platform(strings.TrimRight(string(p), "/") + "/" + string(libc))
+}
+
+func (l Libc) Normalized() Libc {
+	l = Libc(strings.ToLower(string(l)))
+	if l == "gnu" {
+		return LibcGlibc
+	}
+	return l
+}
+
+func (t PlatformTable) Normalize(p Platform) Platform {
 	os, arch, variant := p.Split()
 	if os == "" {
 		return ""
 	}
+
+	os = t.NormalizeOs(os)
 	if arch == "" {
 		return Platform(os)
 	}
 
-	arch_ := Arch(arch)
-	switch Arch(arch) {
-	case ArchX86_64:
-		arch_ = ArchAmd64
-	case ArchAArch32:
-		arch_ = ArchArm
-	case ArchAArch64:
-		arch_ = ArchArm64
+	arch, variant_ := t.NormalizeArch(os, arch)
+	variant = Variant(strings.TrimRight(string(variant), "/"))
+	if variant == "" {
+		variant = variant_
 	}
 
-	p_ := string(p)
-	if arch_ != Arch(arch) {
-		p_ = strings.Join([]string{string(os), string(arch_), string(variant)}, "/")
+	vs := []string{string(os), string(arch)}
+	if variant != "" {
+		vs = append(vs, string(variant))
 	}
-
-	var ok bool
-	for {
-		if p_, ok = strings.CutSuffix(p_, "/"); !ok {
-			break
-		}
+	if libc := p.Libc(); libc != "" {
+		vs = append(vs, string(libc.Normalized()))
 	}
 
-	return Platform(p_)
+	return Platform(strings.Join(vs, "/"))
 }
 
-func (p Platform) Expand() iter.Seq[Platform] {
-	os, arch, variant := p.Normalized().Split()
+// Expand returns every spelling of the platforms the pattern matches.
+// Opt-in architectures are expanded only if they are given.
+func (t PlatformTable) Expand(p Platform, optin ...Arch) iter.Seq[Platform] {
+	os, arch, variant := t.Normalize(p).Split()
+	libc := t.Normalize(p).Libc()
 	if os == "" || arch == "" {
 		return func(yield func(Platform) bool) {}
 	}
@@ -108,203 +163,262 @@ func (p Platform) Expand() iter.Seq[Platform] {
 		}
 	}
 
-	return func(yield func(Platform) bool) {
-		oses := []Os{}
-		switch os {
-		case "_":
-			oses = []Os{
-				OsLinux,
-				OsWindows,
-				OsDarwin,
-			}
-		default:
-			oses = []Os{Os(os)}
-		}
+	oses := []Os{os}
+	if os == "_" {
+		oses = t.Oses()
+	} else if strings.HasPrefix(string(os), "_") {
+		// Unknown wildcard.
+		return func(yield func(Platform) bool) {}
+	}
+	if _, ok := archWildcards[arch]; !ok && strings.HasPrefix(string(arch), "_") {
+		// Unknown wildcard.
+		return func(yield func(Platform) bool) {}
+	}
 
-		archs := []Arch{}
+	return func(yield func(Platform) bool) {
 		for _, os := range oses {
-			switch os {
-			case OsLinux:
-				switch arch {
-				case "_":
-					archs = []Arch{
-						"x86",
-						"x86_64",
-						"aarch32",
-						"aarch64",
-						"amd64",
-						"arm64",
-					}
-
-				case "_32":
-					archs = []Arch{
-						"x86",
-						"aarch32",
+			archs := []Arch{arch}
+			if w, ok := archWildcards[arch]; ok {
+				archs = nil
+				spec, _ := t.Spec(os)
+				for _, spelling := range spec.Archs {
+					if w.Match(spelling.Normalized) && spec.Expands(spelling.Normalized, optin...) {
+						archs = append(archs, spelling.Arch)
 					}
+				}
+			}
 
-				case "_64":
-					archs = []Arch{
-						"x86_64",
-						"aarch64",
-						"amd64",
-						"arm64",
-					}
+			libcs := []Libc{libc}
+			if libc == "_" {
+				spec, _ := t.Spec(os)
+				libcs = append([]Libc{""}, spec.Libcs...)
+			}
 
-				case "_arm64":
-					archs = []Arch{
-						"aarch64",
-						"arm64",
+			for _, arch := range archs {
+				for _, libc := range libcs {
+					p := string(os) + "/" + string(arch) + "/" + string(variant)
+					if libc != "" {
+						p = strings.TrimRight(p, "/") + "/" + string(libc)
 					}
-
-				case "_amd64":
-					archs = []Arch{
-						"x86_64",
-						"amd64",
+					if !yield(Platform(p)) {
+						return
 					}
 				}
+			}
+		}
+	}
+}
 
-			case OsWindows:
-				switch arch {
-				case "_":
-					archs = []Arch{
-						"AMD64",
-						"x86",
-						"ARM64",
-						"ARM",
-					}
+type archWildcard struct {
+	// How specific the wildcard is.
+	Score int
+	Match func(a Arch) bool
+}
 
-				case "_32":
-					archs = []Arch{
-						"x86",
-						"ARM",
-					}
+var archWildcards = map[Arch]archWildcard{
+	"_":      {1, func(a Arch) bool { return true }},
+	"_32":    {2, Arch.Is32},
+	"_64":    {2, Arch.Is64},
+	"_amd":   {2, Arch.IsAmd},
+	"_arm":   {2, Arch.IsArm},
+	"_amd32": {4, Arch.IsAmd32},
+	"_arm32": {4, Arch.IsArm32},
+	"_amd64": {4, Arch.IsAmd64},
+	"_arm64": {4, Arch.IsArm64},
+}
 
-				case "_64":
-					archs = []Arch{
-						"AMD64",
-						"ARM64",
-					}
+type PlatformMap map[Platform]Platform
 
-				case "_arm64":
-					archs = []Arch{
-						"ARM64",
-					}
+func (m PlatformMap) Merge(other PlatformMap) PlatformMap {
+	if len(other) == 0 {
+		return m
+	}
 
-				case "_amd64":
-					archs = []Arch{
-						"AMD64",
-					}
-				}
+	m_ := maps.Clone(m)
+	if m_ == nil {
+		m_ = PlatformMap{}
+	}
+	maps.Copy(m_, other)
+	return m_
+}
 
-			case OsDarwin:
-				switch arch {
-				case "_":
-					archs = []Arch{
-						"x86_64",
-						"arm64",
-					}
-				}
-			}
-		}
+func (t PlatformTable) ExpandMap(m PlatformMap, optin ...Arch) map[Platform][]Platform {
+	m_ := make(map[Platform][]Platform)
+	for _, pattern := range slices.Sorted(maps.Keys(m)) {
+		v := m[pattern]
+		m_[v] = append(m_[v], slices.Collect(t.Expand(pattern, optin...))...)
+	}
 
-		for _, arch := range archs {
-			if !yield(Platform(string(os) + "/" + string(arch) + "/" + string(variant))) {
-				return
+	return m_
+}
+
+func (t PlatformTable) Platforms(m PlatformMap, optin ...Arch) []Platform {
+	vs := []Platform{}
+	for pattern := range m {
+		for p := range t.Expand(pattern, optin...) {
+			p = t.Normalize(p)
+			if slices.Contains(vs, p) {
+				continue
 			}
+			vs = append(vs, p)
 		}
 	}
-}
+	slices.Sort(vs)
 
-type PlatformMap map[Platform]Platform
+	return vs
+}
 
-func (m PlatformMap) Expand() map[Platform][]Platform {
-	m_ := make(map[Platform][]Platform)
-	for pattern, v := range m {
-		m_[v] = slices.Collect(pattern.Expand())
+func (t PlatformTable) Resolve(m PlatformMap, p Platform, optin ...Arch) (Platform, bool) {
+	k, ok := t.Match(m, p, optin...)
+	if !ok {
+		return "", false
 	}
 
-	return m_
+	return m[k], true
 }
 
-func (m PlatformMap) Resolve(p Platform) (Platform, bool) {
+// Match returns the pattern that matches the platform best.
+func (t PlatformTable) Match(m PlatformMap, p Platform, optin ...Arch) (Platform, bool) {
 	var (
 		match Platform
 		score = 0
 	)
+	for k, score_ := range t.Scores(m, p, optin...) {
+		if score_ == 0 || score_ < score || (score_ == score && k > match) {
+			continue
+		}
 
-	os, arch, _ := p.Normalized().Split()
-	if os == "" || arch == "" {
+		match = k
+		score = score_
+	}
+	if score == 0 {
 		return "", false
 	}
 
-	for k, v := range m {
+	return match, true
+}
+
+// Scores returns how well each pattern matches the platform, or 0 if it does not.
+// OS and architecture weigh most, then the libc, then the variant.
+func (t PlatformTable) Scores(m PlatformMap, p Platform, optin ...Arch) map[Platform]int {
+	vs := make(map[Platform]int, len(m))
+	for k := range m {
+		vs[k] = 0
+	}
+
+	os, arch, variant := t.Normalize(p).Split()
+	if os == "" || arch == "" {
+		return vs
+	}
+	if variant == "" {
+		variant = arch.DefaultVariant()
+	}
+
+	libc := t.Normalize(p).Libc()
+	if libc == "" {
+		libc = t.DefaultLibc(os)
+	}
+	spec, _ := t.Spec(os)
+	for k := range m {
 		score_ := 0
-		os_, arch_, _ := k.Split()
-		if os_ == "" {
-			continue
-		}
-		if arch_ == "" {
+		os_, arch_, variant_ := k.Split()
+		if os_ == "" || arch_ == "" {
 			continue
 		}
+		variant_ = Variant(strings.Trim(string(variant_), "/"))
 
-		switch os_ {
+		switch t.NormalizeOs(os_) {
 		case "_":
+			if !slices.Contains(t.Oses(), os) {
+				continue
+			}
 			score_ += 1
 		case os:
 			score_ += 8
+		default:
+			continue
 		}
 
-		switch arch_ {
-		case "_":
-			score_ += 1
-		case "_amd":
-			if arch.IsAmd() {
-				score_ += 2
+		if w, ok := archWildcards[arch_]; ok {
+			if !w.Match(arch) || !t.Knows(os, arch, optin...) {
+				continue
 			}
-		case "_arm":
-			if arch.IsArm() {
-				score_ += 2
+			score_ += w.Score
+		} else {
+			if t.Normalize(Platform(string(os)+"/"+string(arch_))).Arch() != arch {
+				continue
 			}
-		case "_32":
-			if arch.Is32() {
-				score_ += 2
-			}
-		case "_64":
-			if !arch.Is64() {
-				score_ += 2
-			}
-		case "_amd32":
-			if arch.IsAmd32() {
-				score_ += 4
-			}
-		case "_arm32":
-			if arch.IsArm32() {
-				score_ += 4
-			}
-		case "_amd64":
-			if arch.IsAmd64() {
-				score_ += 4
-			}
-		case "_arm64":
-			if arch.IsArm64() {
-				score_ += 4
-			}
-		case arch:
 			score_ += 8
 		}
 
-		if score_ < score {
+		score_ *= 4
+		switch libc_ := k.Libc().Normalized(); libc_ {
+		case libc:
+			score_ += 3
+		case "_":
+			if libc != "" && !slices.Contains(spec.Libcs, libc) {
+				continue
+			}
+			score_ += 2
+		case "":
+			score_ += 1
+		default:
+			continue
+		}
+
+		score_ *= 8
+		switch {
+		case variant_ == variant:
+			score_ += 4
+		case variant_ == "":
+			score_ += 1
+		case slices.Contains(variant.Fallbacks(arch), variant_):
+			score_ += 3 - slices.Index(variant.Fallbacks(arch), variant_)
+		case variant == "":
+		default:
 			continue
 		}
 
-		match = v
-		score = score_
+		vs[k] = score_
 	}
-	if score < 0 {
-		return "", false
+
+	return vs
+}
+
+func (a Arch) DefaultVariant() Variant {
+	switch a {
+	case ArchArm64:
+		return VariantArmV8
+	default:
+		return ""
 	}
+}
 
-	return match, true
+func (a Arch) Variants() []Variant {
+	switch a {
+	case ArchArm:
+		return []Variant{VariantArmV6, VariantArmV7, VariantArmV8}
+	case ArchArm64:
+		return []Variant{VariantArmV8}
+	default:
+		return nil
+	}
+}
+
+// Fallbacks returns the variants that can run on the variant, e.g. v6 on ARMv7.
+func (v Variant) Fallbacks(arch Arch) []Variant {
+	if arch != ArchArm {
+		return nil
+	}
+
+	switch v {
+	case VariantArmV8:
+		return []Variant{VariantArmV7, VariantArmV6}
+	case VariantArmV7:
+		return []Variant{VariantArmV6}
+	default:
+		return nil
+	}
 }
 
 func (a Arch) Is32() bool {
@@ -318,7 +432,7 @@ func (a Arch) Is32() bool {
 
 func (a Arch) Is64() bool {
 	switch a {
-	case ArchArm64, ArchAmd64:
+	case ArchArm64, ArchAmd64, ArchRiscv64, ArchPpc64le, ArchS390x, ArchLoong64, ArchMips64le:
 		return true
 	default:
 		return false
@@ -378,3 +492,48 @@ func (a Arch) IsArm64() bool {
 		return false
 	}
 }
+
+func (a Arch) IsRiscv() bool {
+	switch a {
+	case ArchRiscv64:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a Arch) IsPpc() bool {
+	switch a {
+	case ArchPpc64le:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a Arch) IsS390() bool {
+	switch a {
+	case ArchS390x:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a Arch) IsLoong() bool {
+	switch a {
+	case ArchLoong64:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a Arch) IsMips() bool {
+	switch a {
+	case ArchMips64le:
+		return true
+	default:
+		return false
+	}
+}
@@ -10,6 +10,8 @@ import (
 )
 
 func TestPlatform(t *testing.T) {
+	table := arks.DefaultPlatformTable()
+
 	t.Run("Split", func(t *testing.T) {
 		tests := [][]string{
 			{"", "", "", ""},
@@ -24,19 +26,41 @@ func TestPlatform(t *testing.T) {
 			{"linux//", "linux", "", ""},
 			{"linux//v7", "linux", "", "v7"},
 			{"linux//v7/", "linux", "", "v7/"},
+			{"linux/amd64/musl", "linux", "amd64", ""},
+			{"linux/amd64/musl/", "linux", "amd64", ""},
+			{"linux/arm/v7/musl", "linux", "arm", "v7"},
+			{"linux/amd64/_", "linux", "amd64", ""},
+			{"linux/amd64/v2", "linux", "amd64", "v2"},
 		}
 		for _, test := range tests {
 			p := arks.Platform(test[0])
 			a, b, c := p.Split()
 			t.Run(fmt.Sprintf("(%s)->%s,%s,%s", p, a, b, c), func(t *testing.T) {
 				x := require.New(t)
-				x.Equal(test[1], a)
-				x.Equal(test[2], b)
-				x.Equal(test[3], c)
+				x.Equal(test[1], string(a))
+				x.Equal(test[2], string(b))
+				x.Equal(test[3], string(c))
+			})
+		}
+	})
+	t.Run("Libc", func(t *testing.T) {
+		tests := [][]string{
+			{"linux/amd64", ""},
+			{"linux/amd64/", ""},
+			{"linux/amd64/musl", "musl"},
+			{"linux/amd64/musl/", "musl"},
+			{"linux/arm/v7/glibc", "glibc"},
+			{"linux/amd64/_", "_"},
+			{"linux/arm/v7", ""},
+			{"linux/musl", ""},
+		}
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("(%s)->%s", test[0], test[1]), func(t *testing.T) {
+				require.Equal(t, arks.Libc(test[1]), arks.Platform(test[0]).Libc())
 			})
 		}
 	})
-	t.Run("Normalized", func(t *testing.T) {
+	t.Run("Normalize", func(t *testing.T) {
 		tests := [][]string{
 			{"", ""},
 			{"/", ""},
@@ -70,11 +94,53 @@ func TestPlatform(t *testing.T) {
 
 			{"linux/x86_64", "linux/amd64"},
 			{"linux/aarch32", "linux/arm"},
+			{"windows/AMD64", "windows/amd64"},
+			{"windows/ARM64", "windows/arm64"},
+			{"windows/ARM", "windows/arm"},
+
+			// uname -s and uname -m
+			{"Linux/x86_64", "linux/amd64"},
+			{"Linux/aarch64", "linux/arm64"},
+			{"Linux/armv7l", "linux/arm/v7"},
+			{"Linux/armv6l", "linux/arm/v6"},
+			{"Linux/armv8l", "linux/arm/v8"},
+			{"Linux/i686", "linux/x86"},
+			{"Linux/i386", "linux/x86"},
+			{"Darwin/arm64", "darwin/arm64"},
+			// $env:OS and $env:PROCESSOR_ARCHITECTURE
+			{"Windows_NT/AMD64", "windows/amd64"},
+			{"windows/x86", "windows/x86"},
+			{"windows/ARM64", "windows/arm64"},
+			// Docker TARGETOS, TARGETARCH and TARGETVARIANT
+			{"linux/386", "linux/x86"},
+			{"linux/arm/v7", "linux/arm/v7"},
+			{"linux/arm64/v8", "linux/arm64/v8"},
+			// Variant given explicitly wins.
+			{"linux/armv7l/v6", "linux/arm/v6"},
+			// Case-insensitive.
+			{"LINUX/X86_64", "linux/amd64"},
+			{"linux/Armv7L", "linux/arm/v7"},
+			{"windows/amd64", "windows/amd64"},
+			{"FreeBSD/RISCV64", "freebsd/riscv64"},
+			{"Linux/loongarch64", "linux/loong64"},
+			{"linux/mips64el", "linux/mips64le"},
+			{"linux/ppc64el", "linux/ppc64le"},
+			{"linux/s390x", "linux/s390x"},
+			{"SunOS/i86pc", "sunos/amd64"},
+			{"illumos/i86pc", "illumos/amd64"},
+			{"Android/aarch64", "android/arm64"},
+			{"android/armeabi-v7a", "android/arm/v7"},
+			{"android/arm64-v8a", "android/arm64"},
+			// libc
+			{"linux/x86_64/musl", "linux/amd64/musl"},
+			{"linux/x86_64/gnu", "linux/amd64/glibc"},
+			{"Linux/armv7l/MUSL/", "linux/arm/v7/musl"},
+			{"linux/arm/v6/musl", "linux/arm/v6/musl"},
 		}
 		for _, test := range tests {
 			t.Run(fmt.Sprintf("(%s)->%s", test[0], test[1]), func(t *testing.T) {
 				x := require.New(t)
-				p := arks.Platform(test[0]).Normalized()
+				p := table.Normalize(arks.Platform(test[0]))
 				x.Equal(arks.Platform(test[1]), p)
 			})
 		}
@@ -129,17 +195,319 @@ func TestPlatform(t *testing.T) {
 				"windows/ARM64/",
 			},
 
+			{"windows/_amd32/",
+				"windows/x86/",
+			},
+			{"windows/_arm32/",
+				"windows/ARM/",
+			},
+			{"windows/_amd/",
+				"windows/AMD64/",
+				"windows/x86/",
+			},
+			{"windows/_arm/",
+				"windows/ARM64/",
+				"windows/ARM/",
+			},
+
 			{"darwin/_/",
 				"darwin/x86_64/",
 				"darwin/arm64/",
 			},
+			{"darwin/_32/"},
+			{"darwin/_64/",
+				"darwin/x86_64/",
+				"darwin/arm64/",
+			},
+			{"darwin/_amd64/",
+				"darwin/x86_64/",
+			},
+
+			{"_/_arm64/",
+				"linux/aarch64/",
+				"linux/arm64/",
+				"windows/ARM64/",
+				"darwin/arm64/",
+			},
+			{"_/amd64/",
+				"linux/amd64/",
+				"windows/amd64/",
+				"darwin/amd64/",
+			},
+
+			{"freebsd/_/",
+				"freebsd/amd64/",
+				"freebsd/i386/",
+				"freebsd/arm64/",
+			},
+			{"openbsd/_64/",
+				"openbsd/amd64/",
+				"openbsd/arm64/",
+			},
+			{"netbsd/_32/",
+				"netbsd/i386/",
+			},
+			{"android/_arm/",
+				"android/aarch64/",
+				"android/arm/",
+			},
+			{"illumos/_/",
+				"illumos/amd64/",
+			},
+
+			{"linux/_amd64/musl",
+				"linux/x86_64/musl",
+				"linux/amd64/musl",
+			},
+			{"linux/_arm32/v7/musl/",
+				"linux/aarch32/v7/musl",
+			},
+			{"linux/amd64/_",
+				"linux/amd64/",
+				"linux/amd64/glibc",
+				"linux/amd64/musl",
+			},
+			{"_/_arm64/_",
+				"linux/aarch64/",
+				"linux/aarch64/glibc",
+				"linux/aarch64/musl",
+				"linux/arm64/",
+				"linux/arm64/glibc",
+				"linux/arm64/musl",
+				"windows/ARM64/",
+				"darwin/arm64/",
+			},
+
+			{"linux/amd64/", "linux/amd64/"},
+			{"linux/amd64/musl", "linux/amd64/musl"},
+			{"linux/riscv64/", "linux/riscv64/"},
+			{"linux/_foo/"},
+			{"_foo/_/"},
 		}
 		for _, test := range tests {
 			t.Run(fmt.Sprintf("Platform(%q).Expand", test[0]), func(t *testing.T) {
 				x := require.New(t)
-				vs := slices.Collect(test[0].Expand())
+				vs := slices.Collect(table.Expand(test[0]))
+				if len(test) == 1 {
+					x.Empty(vs)
+					return
+				}
 				x.Equal(test[1:], vs)
 			})
 		}
 	})
+	t.Run("Expand opt-in", func(t *testing.T) {
+		tests := []struct {
+			pattern arks.Platform
+			optin   []arks.Arch
+			want    []arks.Platform
+		}{
+			{"linux/_64/", []arks.Arch{"riscv64", "ppc64le"}, []arks.Platform{
+				"linux/x86_64/",
+				"linux/aarch64/",
+				"linux/amd64/",
+				"linux/arm64/",
+				"linux/riscv64/",
+				"linux/ppc64le/",
+			}},
+			{"linux/_/", []arks.Arch{"s390x", "loong64", "mips64le"}, []arks.Platform{
+				"linux/x86/",
+				"linux/x86_64/",
+				"linux/aarch32/",
+				"linux/aarch64/",
+				"linux/amd64/",
+				"linux/arm64/",
+				"linux/s390x/",
+				"linux/loong64/",
+				"linux/mips64le/",
+			}},
+			{"freebsd/_64/", []arks.Arch{"riscv64"}, []arks.Platform{
+				"freebsd/amd64/",
+				"freebsd/arm64/",
+				"freebsd/riscv64/",
+			}},
+			{"darwin/_64/", []arks.Arch{"riscv64"}, []arks.Platform{
+				"darwin/x86_64/",
+				"darwin/arm64/",
+			}},
+		}
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("Platform(%q).Expand(%v)", test.pattern, test.optin), func(t *testing.T) {
+				require.Equal(t, test.want, slices.Collect(table.Expand(test.pattern, test.optin...)))
+			})
+		}
+	})
+	t.Run("Match libc", func(t *testing.T) {
+		m := arks.PlatformMap{
+			"linux/_amd64/":      "amd64",
+			"linux/_amd64/musl/": "amd64 musl",
+			"linux/_arm64/glibc": "arm64 glibc",
+			"linux/_arm/v7/_":    "arm v7",
+			"windows/_amd64/":    "windows",
+		}
+		tests := [][]arks.Platform{
+			{"linux/amd64", "linux/_amd64/"},
+			{"linux/amd64/glibc", "linux/_amd64/"},
+			{"linux/amd64/musl", "linux/_amd64/musl/"},
+			{"linux/x86_64/MUSL", "linux/_amd64/musl/"},
+			{"linux/arm64", "linux/_arm64/glibc"},
+			{"linux/arm64/gnu", "linux/_arm64/glibc"},
+			{"linux/arm64/musl", ""},
+			{"linux/arm/v7/musl", "linux/_arm/v7/_"},
+			{"linux/arm/v8", "linux/_arm/v7/_"},
+			{"windows/amd64", "windows/_amd64/"},
+			{"windows/amd64/musl", "windows/_amd64/"},
+		}
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%s->%s", test[0], test[1]), func(t *testing.T) {
+				k, ok := table.Match(m, test[0])
+				require.Equal(t, test[1] != "", ok)
+				require.Equal(t, test[1], k)
+			})
+		}
+
+		t.Run("libc preferred over variant", func(t *testing.T) {
+			m := arks.PlatformMap{
+				"linux/arm/v7/":      "armv7",
+				"linux/arm/v6/musl/": "armv6 musl",
+			}
+			k, _ := table.Match(m, "linux/arm/v7/musl")
+			require.Equal(t, arks.Platform("linux/arm/v6/musl/"), k)
+			k, _ = table.Match(m, "linux/arm/v7")
+			require.Equal(t, arks.Platform("linux/arm/v7/"), k)
+		})
+	})
+	t.Run("Expand and Match agree", func(t *testing.T) {
+		oses := []arks.Os{"linux", "windows", "darwin", "freebsd", "openbsd", "netbsd", "android", "illumos", "plan9"}
+		wildcards := []arks.Arch{"_", "_32", "_64", "_amd", "_arm", "_amd32", "_arm32", "_amd64", "_arm64", "_/_", "_64/musl"}
+		archs := []arks.Arch{"x86", "x86_64", "amd64", "AMD64", "aarch32", "arm", "ARM", "aarch64", "arm64", "ARM64", "riscv64", "ppc64le", "s390x", "loong64", "mips64le"}
+		optins := [][]arks.Arch{nil, {"riscv64", "ppc64le"}}
+		for _, optin := range optins {
+			for _, pattern_os := range append([]arks.Os{"_"}, oses...) {
+				for _, wildcard := range wildcards {
+					pattern := arks.Platform(string(pattern_os) + "/" + string(wildcard) + "/")
+					expanded := []arks.Platform{}
+					for p := range table.Expand(pattern, optin...) {
+						expanded = append(expanded, table.Normalize(p))
+					}
+
+					m := arks.PlatformMap{pattern: "foo"}
+					for _, os := range oses {
+						for _, arch := range archs {
+							p := arks.Platform(string(os) + "/" + string(arch))
+							t.Run(fmt.Sprintf("%s<-%s%v", pattern, p, optin), func(t *testing.T) {
+								_, ok := table.Match(m, p, optin...)
+								require.Equal(t, slices.Contains(expanded, table.Normalize(p)), ok)
+							})
+						}
+					}
+				}
+			}
+		}
+	})
+	t.Run("Match", func(t *testing.T) {
+		m := arks.PlatformMap{
+			"_/_/":           "any",
+			"linux/_/":       "linux",
+			"linux/_64/":     "linux 64",
+			"linux/_amd64/":  "linux amd64",
+			"linux/x86_64/":  "linux x86_64",
+			"windows/_amd/":  "windows amd",
+			"windows/_arm32": "windows arm32",
+		}
+		tests := [][]arks.Platform{
+			{"linux/x86_64", "linux/x86_64/"},
+			{"linux/amd64", "linux/x86_64/"},
+			{"linux/aarch64", "linux/_64/"},
+			{"linux/x86", "linux/_/"},
+			{"windows/x86", "windows/_amd/"},
+			{"windows/ARM", "windows/_arm32"},
+			{"windows/ARM64", "_/_/"},
+			{"darwin/arm64", "_/_/"},
+			{"freebsd/amd64", ""},
+			{"linux/riscv64", ""},
+			{"linux", ""},
+		}
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%s->%s", test[0], test[1]), func(t *testing.T) {
+				k, ok := table.Match(m, test[0])
+				require.Equal(t, test[1] != "", ok)
+				require.Equal(t, test[1], k)
+			})
+		}
+	})
+	t.Run("Match variants", func(t *testing.T) {
+		m := arks.PlatformMap{
+			"linux/arm/v6/":   "armv6",
+			"linux/arm/v7/":   "armv7",
+			"linux/arm64/":    "arm64",
+			"linux/_amd64/":   "amd64",
+			"linux/arm64/v9/": "arm64 v9",
+		}
+		tests := [][]arks.Platform{
+			{"linux/arm/v7", "linux/arm/v7/"},
+			{"linux/arm/v6", "linux/arm/v6/"},
+			{"linux/arm/v8", "linux/arm/v7/"},
+			{"linux/arm", "linux/arm/v6/"},
+			{"linux/arm/v5", ""},
+			{"linux/arm64/v8", "linux/arm64/"},
+			{"linux/arm64", "linux/arm64/"},
+			{"linux/amd64/v2", "linux/_amd64/"},
+		}
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%s->%s", test[0], test[1]), func(t *testing.T) {
+				k, ok := table.Match(m, test[0])
+				require.Equal(t, test[1] != "", ok)
+				require.Equal(t, test[1], k)
+			})
+		}
+
+		t.Run("fallback preferred over generic", func(t *testing.T) {
+			m := arks.PlatformMap{
+				"linux/arm/":    "arm",
+				"linux/arm/v6/": "armv6",
+			}
+			k, _ := table.Match(m, "linux/arm/v7")
+			require.Equal(t, arks.Platform("linux/arm/v6/"), k)
+			k, _ = table.Match(m, "linux/arm")
+			require.Equal(t, arks.Platform("linux/arm/"), k)
+		})
+	})
+}
+
+func TestArch(t *testing.T) {
+	tests := []struct {
+		arch arks.Arch
+		is   []func(arks.Arch) bool
+	}{
+		{arks.ArchX86, []func(arks.Arch) bool{arks.Arch.Is32, arks.Arch.IsAmd, arks.Arch.IsAmd32}},
+		{arks.ArchAmd64, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsAmd, arks.Arch.IsAmd64}},
+		{arks.ArchArm, []func(arks.Arch) bool{arks.Arch.Is32, arks.Arch.IsArm, arks.Arch.IsArm32}},
+		{arks.ArchArm64, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsArm, arks.Arch.IsArm64}},
+		{arks.ArchRiscv64, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsRiscv}},
+		{arks.ArchPpc64le, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsPpc}},
+		{arks.ArchS390x, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsS390}},
+		{arks.ArchLoong64, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsLoong}},
+		{arks.ArchMips64le, []func(arks.Arch) bool{arks.Arch.Is64, arks.Arch.IsMips}},
+	}
+	preds := []func(arks.Arch) bool{
+		arks.Arch.Is32, arks.Arch.Is64,
+		arks.Arch.IsAmd, arks.Arch.IsArm,
+		arks.Arch.IsAmd32, arks.Arch.IsAmd64, arks.Arch.IsArm32, arks.Arch.IsArm64,
+		arks.Arch.IsRiscv, arks.Arch.IsPpc, arks.Arch.IsS390, arks.Arch.IsLoong, arks.Arch.IsMips,
+	}
+	for _, test := range tests {
+		t.Run(string(test.arch), func(t *testing.T) {
+			n := 0
+			for _, pred := range preds {
+				if pred(test.arch) {
+					n++
+				}
+			}
+			require.Equal(t, len(test.is), n)
+			for _, is := range test.is {
+				require.True(t, is(test.arch))
+			}
+		})
+	}
 }
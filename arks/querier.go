@@ -3,94 +3,205 @@ package arks
 import (
 	"context"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 type Querier interface {
-	// Query returns the target URL for the given item.
+	// Query returns the resolved item for the given item.
 	// If the item is not found, it should return an [os.ErrNotExist].
-	Query(ctx context.Context, v Item) (string, error)
+	Query(ctx context.Context, v Item) (Result, error)
+}
+
+// AppQuerier is a [Querier] that also provides the app of an item.
+type AppQuerier interface {
+	Querier
+	// QueryApp returns the app the given item belongs to along with the config it is built with.
+	// Only path and name of the item are used.
+	// If the app is not found, it should return an [os.ErrNotExist].
+	QueryApp(ctx context.Context, v Item) (Config, App, error)
+	// ListApps returns all the apps.
+	ListApps(ctx context.Context) ([]AppEntry, error)
+}
+
+// Result is a resolved item.
+type Result struct {
+	// Item as it is rendered.
+	// Its version is the whole version line the requested version belongs to.
+	Item
+
+	// Cache config of the app the item belongs to.
+	Cache CacheConfig
 }
 
 type FsQuerier struct {
 	fs.FS
 }
 
-func (q FsQuerier) Query(ctx context.Context, v Item) (string, error) {
-	p := v.Path
-	for {
-		if p == "" {
-			return "", os.ErrNotExist
-		}
-		if p[0] != '/' {
-			break
-		}
-		p = p[1:]
+// findApp returns the app whose origin path matches the given item
+// along with the config it was visited with and the directory it is found in.
+// The app is looked up in the directory the origin path refers first,
+// and the whole port is walked only if it is not there, e.g. the path is renamed by a config.
+func (q FsQuerier) findApp(v Item) (Config, string, App, error) {
+	p := strings.TrimLeft(v.Path, "/")
+	if c, d, app, ok, err := q.stepApp(p, v.Name); err != nil {
+		return Config{}, "", App{}, err
+	} else if ok {
+		return c, d, app, nil
 	}
 
-	f, err := q.Open(p)
+	var (
+		c_    Config
+		p_    string
+		app_  App
+		found bool
+		apps  []string
+	)
+
+	walker := FsWalker{Fs: q.FS.(fs.ReadDirFS)}
+	err := walker.Walk(NewConfig(), ".", func(c Config, d string, app App) error {
+		path := strings.TrimLeft(c.Path, "/")
+		if app.Name != v.Name || path != p {
+			apps = append(apps, path+"/"+app.Name)
+			return nil
+		}
+
+		c_ = c
+		p_ = d
+		app_ = app
+		found = true
+
+		// Stop walking.
+		return fs.SkipAll
+	})
+	if found {
+		return c_, p_, app_, nil
+	}
 	if err != nil {
-		return "", err
+		return Config{}, "", App{}, err
 	}
-	if info, err := f.Stat(); err != nil {
-		return "", err
-	} else if !info.IsDir() {
-		return "", os.ErrNotExist
+
+	name := p + "/" + v.Name
+	return Config{}, "", App{}, &AppNotFoundError{
+		App:         name,
+		Suggestions: suggest(name, apps),
 	}
+}
+
+// stepApp steps through each directory from the root of the port to the directory
+// the given origin path and name refer as they are, and returns the app in it.
+// It reports false if the directory does not exist or its app is rendered with another origin path.
+func (q FsQuerier) stepApp(p string, name string) (Config, string, App, bool, error) {
+	d := filepath.Join(p, name)
 
-	app := App{}
-	found := false
+	var (
+		c_    Config
+		app_  App
+		found bool
+	)
 
 	c := NewConfig()
 	walker := FsWalker{Fs: q.FS.(fs.ReadDirFS)}
-	for i, r := range p {
-		p_ := p[:i]
-		if i == len(p)-1 && r != '/' {
-			// Last entry.
-			p_ = p
-		} else if r != '/' {
-			continue
+	for _, d_ := range dirChain(d) {
+		if info, err := fs.Stat(q.FS, d_); err != nil || !info.IsDir() {
+			return Config{}, "", App{}, false, nil
 		}
 
-		c, err = walker.Step(c, p_, func(c Config, p string, a App) error {
-			app = a
+		var err error
+		c, err = walker.Step(c, d_, func(c Config, p string, app App) error {
+			if p != d {
+				return nil
+			}
+
+			c_ = c
+			app_ = app
 			found = true
 			return nil
 		})
 		if err != nil {
-			return "", err
+			return Config{}, "", App{}, false, fmt.Errorf("%s: %w", d_, err)
 		}
 	}
+	if !found || app_.Name != name || strings.TrimLeft(c_.Path, "/") != p {
+		return Config{}, "", App{}, false, nil
+	}
 
-	if !found {
-		return "", io.EOF
+	return c_, d, app_, true, nil
+}
+
+func (q FsQuerier) QueryApp(ctx context.Context, v Item) (Config, App, error) {
+	c, _, app, err := q.findApp(v)
+	return c, app, err
+}
+
+func (q FsQuerier) ListApps(ctx context.Context) ([]AppEntry, error) {
+	vs := []AppEntry{}
+	err := FsWalker{Fs: q.FS.(fs.ReadDirFS)}.Walk(NewConfig(), ".", func(c Config, _ string, app App) error {
+		vs = append(vs, AppEntry{c, app})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+func (q FsQuerier) Query(ctx context.Context, v Item) (Result, error) {
+	c, _, app, err := q.findApp(v)
+	if err != nil {
+		return Result{}, err
 	}
 
-	platform, ok := app.Platforms.Resolve(v.Platform)
+	return resolve(c, app, v)
+}
+
+// resolve builds the given item from the app visited with the given config.
+// The platform of the item is resolved by the platform map of the app.
+func resolve(c Config, app App, v Item) (Result, error) {
+	name := strings.TrimLeft(v.Path, "/") + "/" + v.Name
+	version, ok := app.FindVersion(v.Version.String())
 	if !ok {
-		return "", os.ErrNotExist
+		return Result{}, &VersionNotFoundError{
+			App:      name,
+			Version:  v.Version.String(),
+			Versions: app.Versions,
+		}
 	}
 
-	app.Versions = []Version{v.Version}
-	app.Platforms = PlatformMap{platform: platform}
+	pattern, fallback, ok := c.fallback(app, v.Platform)
+	if !ok {
+		return Result{}, &PlatformNotSupportedError{
+			App:       name,
+			Platform:  v.Platform,
+			Platforms: c.table().Platforms(app.Platforms, app.Archs...),
+		}
+	}
+
+	app.Versions = []Version{version}
+	app.Platforms = PlatformMap{v.Platform: app.Platforms[pattern]}
+	c.Fallback = nil
 
 	build, err := c.Build(app)
 	if err != nil {
-		return "", fmt.Errorf("prepare build for app: %w", err)
+		return Result{}, fmt.Errorf("prepare build for app: %w", err)
 	}
 
+	k := origin(c.Path, app.Name, v.Version.String(), v.Platform)
 	for items, err := range build {
 		if err != nil {
-			return "", fmt.Errorf("build app: %w", err)
+			return Result{}, fmt.Errorf("build app: %w", err)
 		}
-		if len(items) == 0 {
-			return "", os.ErrNotExist
+		for _, item := range items {
+			if item.Origin == k {
+				item.Pattern = pattern
+				item.Fallback = fallback
+				return Result{Item: item, Cache: c.Cache}, nil
+			}
 		}
-
-		return items[0].Target, nil
 	}
 
-	return "", os.ErrNotExist
+	return Result{}, os.ErrNotExist
 }
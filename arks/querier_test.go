@@ -0,0 +1,380 @@
+package arks_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func testPort() fstest.MapFS {
+	return fstest.MapFS{
+		"github.com/config.yaml": &fstest.MapFile{Data: []byte(`
+path: ".."
+target:
+  suffix: /releases/download/
+`)},
+		"github.com/lesomnus/arrakis/arks/app.yaml": &fstest.MapFile{Data: []byte(`
+path: v{{.Version}}/arks-{{.Os}}-{{.Arch}}
+checksum: v{{.Version}}/arks-{{.Os}}-{{.Arch}}.sha256
+platforms:
+  linux/_amd64/: linux/amd64/
+  linux/_arm64/: linux/arm64/
+`)},
+		"github.com/lesomnus/arrakis/arks/versions": &fstest.MapFile{Data: []byte(`
+0.0.1
+0.0.2 latest
+`)},
+		"github.com/protocolbuffers/protobuf/protoc/app.yaml": &fstest.MapFile{Data: []byte(`
+path: v{{.Version}}/protoc-{{.Version}}-{{.Os}}{{.Arch | prefix "-"}}.zip
+platforms:
+  linux/_amd64/: linux/x86_64/
+  linux/_arm64/: linux/aarch_64/
+  windows/_amd64/: win64//
+cache:
+  alias: 1m
+`)},
+		"github.com/protocolbuffers/protobuf/protoc/versions": &fstest.MapFile{Data: []byte(`
+33.4
+33.5 33 latest
+`)},
+		"example.com/config.yaml": &fstest.MapFile{Data: []byte(`
+target:
+  suffix: /dl/
+fallback:
+  darwin/arm64: darwin/amd64
+  windows/_arm64/: windows/amd64
+  linux/_amd64/: linux/x86
+`)},
+		"example.com/fallback/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/fallback-{{.Os}}-{{.Arch}}"
+platforms:
+  darwin/_amd64/: darwin/amd64/
+  linux/_32/: linux/386/
+  linux/_arm64/: linux/arm64/
+`)},
+		"example.com/fallback/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+		"example.com/nofallback/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/nofallback-{{.Os}}-{{.Arch}}"
+platforms:
+  darwin/_amd64/: darwin/amd64/
+fallback:
+  darwin/arm64: ""
+`)},
+		"example.com/nofallback/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+		"example.com/variant/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/variant-{{.Arch}}{{.Variant | prefix \"-\"}}"
+platforms:
+  linux/arm/v6/: linux/arm/v6
+  linux/arm/v7/: linux/arm/v7
+`)},
+		"example.com/variant/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+		"example.com/libc/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/libc-{{.Arch}}-{{.Libc}}"
+platforms:
+  linux/_amd64/: linux/amd64/
+  linux/_amd64/musl: linux/amd64/musl
+  darwin/_arm64/: darwin/arm64/
+`)},
+		"example.com/libc/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+		"example.com/optin/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/optin-{{.Os}}"
+platforms:
+  linux/_64/: linux
+archs: [riscv64]
+`)},
+		"example.com/optin/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+		"example.com/optout/app.yaml": &fstest.MapFile{Data: []byte(`
+path: "{{.Version}}/optout-{{.Os}}"
+platforms:
+  linux/_64/: linux
+`)},
+		"example.com/optout/versions": &fstest.MapFile{Data: []byte("1.0\n")},
+	}
+}
+
+// renderedItems returns every item rendered from the given port.
+func renderedItems(t *testing.T, port fs.ReadDirFS) []arks.Item {
+	vs := []arks.Item{}
+	err := arks.FsWalker{Fs: port}.Walk(arks.NewConfig(), ".", func(c arks.Config, p string, app arks.App) error {
+		build, err := c.Build(app)
+		if err != nil {
+			return err
+		}
+		for items, err := range build {
+			if err != nil {
+				return err
+			}
+			vs = append(vs, items...)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, vs)
+	return vs
+}
+
+func TestFsQuerier(t *testing.T) {
+	port := testPort()
+	testQuerier(t, port, arks.FsQuerier{FS: port})
+}
+
+func TestIndexQuerier(t *testing.T) {
+	port := testPort()
+	q, err := arks.NewIndexQuerier(port)
+	require.NoError(t, err)
+	require.Equal(t, len(renderedItems(t, port)), q.Len())
+
+	testQuerier(t, port, q)
+
+	t.Run("same as FsQuerier", func(t *testing.T) {
+		fsq := arks.FsQuerier{FS: port}
+		for _, item := range renderedItems(t, port) {
+			v, err := arks.ParseItem("/" + item.Origin)
+			require.NoError(t, err)
+			expected, err := fsq.Query(context.Background(), v)
+			require.NoError(t, err, item.Origin)
+			actual, err := q.Query(context.Background(), v)
+			require.NoError(t, err, item.Origin)
+			require.Equal(t, expected, actual, item.Origin)
+		}
+	})
+	t.Run("origins", func(t *testing.T) {
+		// Origin -> Fallback
+		origins := map[string]map[string]arks.Platform{}
+		for _, item := range renderedItems(t, port) {
+			if origins[item.Name] == nil {
+				origins[item.Name] = map[string]arks.Platform{}
+			}
+			origins[item.Name][item.Origin] = item.Fallback
+		}
+
+		tests := map[string]map[string]arks.Platform{
+			"variant": {
+				"example.com/variant@1.0/linux/arm/v6": "",
+				"example.com/variant@1.0/linux/arm/v7": "",
+			},
+			"libc": {
+				"example.com/libc@1.0/linux/x86_64":      "",
+				"example.com/libc@1.0/linux/amd64":       "",
+				"example.com/libc@1.0/linux/x86_64/musl": "",
+				"example.com/libc@1.0/linux/amd64/musl":  "",
+				"example.com/libc@1.0/darwin/arm64":      "",
+			},
+			"optin": {
+				"example.com/optin@1.0/linux/x86_64":  "",
+				"example.com/optin@1.0/linux/aarch64": "",
+				"example.com/optin@1.0/linux/amd64":   "",
+				"example.com/optin@1.0/linux/arm64":   "",
+				"example.com/optin@1.0/linux/riscv64": "",
+			},
+			"optout": {
+				"example.com/optout@1.0/linux/x86_64":  "",
+				"example.com/optout@1.0/linux/aarch64": "",
+				"example.com/optout@1.0/linux/amd64":   "",
+				"example.com/optout@1.0/linux/arm64":   "",
+			},
+			"fallback": {
+				"example.com/fallback@1.0/darwin/x86_64": "",
+				"example.com/fallback@1.0/darwin/arm64":  "darwin/amd64",
+				"example.com/fallback@1.0/linux/x86":     "",
+				"example.com/fallback@1.0/linux/aarch32": "",
+				"example.com/fallback@1.0/linux/aarch64": "",
+				"example.com/fallback@1.0/linux/arm64":   "",
+				"example.com/fallback@1.0/linux/x86_64":  "linux/x86",
+				"example.com/fallback@1.0/linux/amd64":   "linux/x86",
+			},
+			"nofallback": {
+				"example.com/nofallback@1.0/darwin/x86_64": "",
+			},
+		}
+		for name, expected := range tests {
+			t.Run(name, func(t *testing.T) {
+				require.Equal(t, expected, origins[name])
+			})
+		}
+	})
+}
+
+func testQuerier(t *testing.T, port fs.ReadDirFS, q arks.Querier) {
+	items := renderedItems(t, port)
+
+	t.Run("rendered items", func(t *testing.T) {
+		for _, item := range items {
+			v, err := arks.ParseItem("/" + item.Origin)
+			require.NoError(t, err)
+
+			res, err := q.Query(context.Background(), v)
+			require.NoError(t, err, item.Origin)
+			require.Equal(t, item, res.Item, item.Origin)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		for _, s := range []string{
+			"/lesomnus/arrakis/arks@0.0.3/linux/amd64",
+			"/lesomnus/arrakis/arks@stable/linux/amd64",
+			"/protocolbuffers/protobuf/protoc@99.99/linux/amd64",
+			"/lesomnus/arrakis/foo@0.0.1/linux/amd64",
+			"/example.com/variant@1.0/linux/arm/v5",
+			"/example.com/libc@1.0/linux/arm64/musl",
+			"/example.com/optout@1.0/linux/riscv64",
+			// No build to fall back to.
+			"/example.com/fallback@1.0/windows/arm64",
+			// Fallback disabled by the app.
+			"/example.com/nofallback@1.0/darwin/arm64",
+		} {
+			v, err := arks.ParseItem(s)
+			require.NoError(t, err)
+
+			_, err = q.Query(context.Background(), v)
+			require.ErrorIs(t, err, os.ErrNotExist, s)
+		}
+	})
+	t.Run("alias", func(t *testing.T) {
+		v, err := arks.ParseItem("/protocolbuffers/protobuf/protoc@latest/linux/x86_64")
+		require.NoError(t, err)
+
+		res, err := q.Query(context.Background(), v)
+		require.NoError(t, err)
+		require.True(t, res.Alias)
+		require.Equal(t, arks.Version("33.5 33 latest"), res.Version)
+		require.Equal(t, time.Minute, res.Cache.Alias)
+		require.Equal(t, arks.NewConfig().Cache.Pinned, res.Cache.Pinned)
+	})
+	t.Run("platforms", func(t *testing.T) {
+		tests := []struct {
+			desc     string
+			origin   string
+			target   string
+			fallback arks.Platform
+		}{
+			{"dialect", "/lesomnus/arrakis/arks@0.0.1/Linux/x86_64", "https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-amd64", ""},
+			{"dialect", "/lesomnus/arrakis/arks@0.0.1/linux/AARCH64", "https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-arm64", ""},
+			{"dialect", "/lesomnus/arrakis/arks@0.0.1/linux/arm64/v8", "https://github.com/lesomnus/arrakis/releases/download/v0.0.1/arks-linux-arm64", ""},
+			{"dialect", "/protocolbuffers/protobuf/protoc@33/Windows_NT/AMD64", "https://github.com/protocolbuffers/protobuf/releases/download/v33.5/protoc-33.5-win64.zip", ""},
+			{"dialect", "/protocolbuffers/protobuf/protoc@33/windows/x64", "https://github.com/protocolbuffers/protobuf/releases/download/v33.5/protoc-33.5-win64.zip", ""},
+			{"variant", "/example.com/variant@1.0/linux/arm/v6", "https://example.com/dl/1.0/variant-arm-v6", ""},
+			{"variant", "/example.com/variant@1.0/linux/arm/v7", "https://example.com/dl/1.0/variant-arm-v7", ""},
+			{"variant", "/example.com/variant@1.0/linux/arm/v8", "https://example.com/dl/1.0/variant-arm-v7", ""},
+			{"variant", "/example.com/variant@1.0/linux/aarch32/v7", "https://example.com/dl/1.0/variant-arm-v7", ""},
+			{"variant", "/example.com/variant@1.0/linux/arm", "https://example.com/dl/1.0/variant-arm-v6", ""},
+			{"libc", "/example.com/libc@1.0/linux/amd64", "https://example.com/dl/1.0/libc-amd64-glibc", ""},
+			{"libc", "/example.com/libc@1.0/linux/x86_64/gnu", "https://example.com/dl/1.0/libc-amd64-glibc", ""},
+			{"libc", "/example.com/libc@1.0/linux/amd64/musl", "https://example.com/dl/1.0/libc-amd64-musl", ""},
+			{"libc", "/example.com/libc@1.0/Linux/x86_64/MUSL", "https://example.com/dl/1.0/libc-amd64-musl", ""},
+			{"libc", "/example.com/libc@1.0/darwin/arm64", "https://example.com/dl/1.0/libc-arm64-", ""},
+			{"opt-in", "/example.com/optin@1.0/linux/riscv64", "https://example.com/dl/1.0/optin-linux", ""},
+			{"fallback", "/example.com/fallback@1.0/darwin/x86_64", "https://example.com/dl/1.0/fallback-darwin-amd64", ""},
+			{"fallback", "/example.com/fallback@1.0/darwin/arm64", "https://example.com/dl/1.0/fallback-darwin-amd64", "darwin/amd64"},
+			{"fallback", "/example.com/fallback@1.0/macos/arm64", "https://example.com/dl/1.0/fallback-darwin-amd64", "darwin/amd64"},
+			{"fallback", "/example.com/fallback@1.0/linux/x86_64", "https://example.com/dl/1.0/fallback-linux-386", "linux/x86"},
+			{"fallback", "/example.com/fallback@1.0/linux/aarch64", "https://example.com/dl/1.0/fallback-linux-arm64", ""},
+		}
+		for _, test := range tests {
+			t.Run(test.desc, func(t *testing.T) {
+				v, err := arks.ParseItem(test.origin)
+				require.NoError(t, err)
+
+				res, err := q.Query(context.Background(), v)
+				require.NoError(t, err, test.origin)
+				require.Equal(t, test.target, res.Target, test.origin)
+				require.Equal(t, test.fallback, res.Fallback, test.origin)
+			})
+		}
+	})
+	t.Run("app not found", func(t *testing.T) {
+		v, err := arks.ParseItem("/lesomnus/arrakis/ark@0.0.1/linux/amd64")
+		require.NoError(t, err)
+
+		_, err = q.Query(context.Background(), v)
+		var target *arks.AppNotFoundError
+		require.ErrorAs(t, err, &target)
+		require.Equal(t, "lesomnus/arrakis/ark", target.App)
+		require.Equal(t, []string{"lesomnus/arrakis/arks"}, target.Suggestions)
+	})
+	t.Run("version not found", func(t *testing.T) {
+		v, err := arks.ParseItem("/lesomnus/arrakis/arks@0.0.3/linux/amd64")
+		require.NoError(t, err)
+
+		_, err = q.Query(context.Background(), v)
+		var target *arks.VersionNotFoundError
+		require.ErrorAs(t, err, &target)
+		require.Equal(t, "0.0.3", target.Version)
+		require.Equal(t, []arks.Version{"0.0.1", "0.0.2 latest"}, target.Versions)
+	})
+	t.Run("platform not supported", func(t *testing.T) {
+		v, err := arks.ParseItem("/lesomnus/arrakis/arks@0.0.1/windows/amd64")
+		require.NoError(t, err)
+
+		_, err = q.Query(context.Background(), v)
+		var target *arks.PlatformNotSupportedError
+		require.ErrorAs(t, err, &target)
+		require.Equal(t, []arks.Platform{"linux/amd64", "linux/arm64"}, target.Platforms)
+	})
+}
+
+func TestFsQuerierLookup(t *testing.T) {
+	ctx := context.Background()
+	port := testPort()
+	// Not visited if the app is looked up by its path.
+	port["example.com/broken/app.yaml"] = &fstest.MapFile{Data: []byte("path: [")}
+	q := arks.FsQuerier{FS: port}
+
+	v, err := arks.ParseItem("/example.com/variant@1.0/linux/arm/v7")
+	require.NoError(t, err)
+	res, err := q.Query(ctx, v)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/dl/1.0/variant-arm-v7", res.Target)
+
+	// Unknown apps are looked up by walking the whole port.
+	v, err = arks.ParseItem("/example.com/baz@1.0/linux/amd64")
+	require.NoError(t, err)
+	_, err = q.Query(ctx, v)
+	require.ErrorContains(t, err, "example.com/broken")
+}
+
+func TestReloadQuerier(t *testing.T) {
+	ctx := context.Background()
+	port := testPort()
+	q, err := arks.NewReloadQuerier(port)
+	require.NoError(t, err)
+
+	v, err := arks.ParseItem("/lesomnus/arrakis/arks@0.0.3/linux/amd64")
+	require.NoError(t, err)
+	_, err = q.Query(ctx, v)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	ok, err := q.Reload(false)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	port["github.com/lesomnus/arrakis/arks/versions"] = &fstest.MapFile{Data: []byte("0.0.3\n")}
+	ok, err = q.Reload(false)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	res, err := q.Query(ctx, v)
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.3/arks-linux-amd64", res.Target)
+
+	// Broken tree keeps the last good index.
+	port["github.com/lesomnus/arrakis/arks/app.yaml"] = &fstest.MapFile{Data: []byte("path: [")}
+	_, err = q.Reload(false)
+	require.ErrorContains(t, err, "github.com/lesomnus/arrakis/arks")
+
+	// Same broken tree is not built again unless forced.
+	ok, err = q.Reload(false)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = q.Reload(true)
+	require.Error(t, err)
+
+	res, err = q.Query(ctx, v)
+	require.NoError(t, err)
+	require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.3/arks-linux-amd64", res.Target)
+}
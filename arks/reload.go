@@ -0,0 +1,114 @@
+package arks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+)
+
+// ReloadQuerier answers queries from an [IndexQuerier] that can be rebuilt
+// while the queries are being served.
+type ReloadQuerier struct {
+	fs fs.ReadDirFS
+
+	curr atomic.Pointer[IndexQuerier]
+
+	mu    sync.Mutex
+	stamp [sha256.Size]byte
+	// Stamp of the port that failed to build last time.
+	stamp_bad [sha256.Size]byte
+}
+
+func NewReloadQuerier(fs fs.ReadDirFS) (*ReloadQuerier, error) {
+	q := &ReloadQuerier{fs: fs}
+	if _, err := q.Reload(true); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Reload rebuilds the index if the port has changed since the last successful build.
+// If force is true, the index is rebuilt regardless of the changes.
+// If the build fails, the last good index keeps being served.
+// It reports whether a new index is swapped in.
+func (q *ReloadQuerier) Reload(force bool) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stamp, err := fingerprint(q.fs)
+	if err != nil {
+		return false, fmt.Errorf("fingerprint port: %w", err)
+	}
+	if !force && q.curr.Load() != nil && (stamp == q.stamp || stamp == q.stamp_bad) {
+		return false, nil
+	}
+
+	index, err := NewIndexQuerier(q.fs)
+	if err != nil {
+		q.stamp_bad = stamp
+		return false, err
+	}
+
+	q.curr.Store(index)
+	q.stamp = stamp
+	return true, nil
+}
+
+// Index returns the index currently being served.
+func (q *ReloadQuerier) Index() *IndexQuerier {
+	return q.curr.Load()
+}
+
+// Table returns the platform table the index currently being served is built with.
+func (q *ReloadQuerier) Table() PlatformTable {
+	return q.curr.Load().Table()
+}
+
+func (q *ReloadQuerier) Query(ctx context.Context, v Item) (Result, error) {
+	return q.curr.Load().Query(ctx, v)
+}
+
+func (q *ReloadQuerier) QueryApp(ctx context.Context, v Item) (Config, App, error) {
+	return q.curr.Load().QueryApp(ctx, v)
+}
+
+// fingerprint digests the path, size, and modification time of every file in the given fs.
+func fingerprint(fsys fs.FS) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(info.Size())))
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(info.ModTime().UnixNano())))
+		return nil
+	})
+
+	var v [sha256.Size]byte
+	if err != nil {
+		return v, err
+	}
+
+	copy(v[:], h.Sum(nil))
+	return v, nil
+}
+
+func (q *ReloadQuerier) ListApps(ctx context.Context) ([]AppEntry, error) {
+	return q.curr.Load().ListApps(ctx)
+}
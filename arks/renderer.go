@@ -1,9 +1,15 @@
 package arks
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"os"
+	"path"
 	"slices"
 	"strings"
 )
@@ -12,6 +18,9 @@ var Renders = map[string](func(io.Writer) Renderer){
 	"kv":   renderCtorFunc(NewKvRenderer),
 	"tree": renderCtorFunc(NewTreePrinter),
 	"cfkv": renderCtorFunc(NewCloudFlareKvRenderer),
+
+	"install": renderCtorFunc(NewInstallScriptRenderer),
+	"index":   renderCtorFunc(NewIndexRenderer),
 }
 
 func renderCtorFunc[T Renderer](f func(io.Writer) T) func(io.Writer) Renderer {
@@ -56,7 +65,17 @@ func NewCloudFlareKvRenderer(w io.Writer) *CloudFlareKvRenderer {
 }
 
 func (p *CloudFlareKvRenderer) Render(c Config, v Item) error {
-	_, err := fmt.Fprintf(p.w, "%s{\"key\":%q,\"value\":%q}", p.s, v.Origin, v.Target)
+	max_age := c.Cache.Pinned
+	if v.Alias {
+		max_age = c.Cache.Alias
+	}
+
+	fallback := ""
+	if v.Fallback != "" {
+		fallback = fmt.Sprintf(",\"fallback\":%q", v.Fallback)
+	}
+
+	_, err := fmt.Fprintf(p.w, "%s{\"key\":%q,\"value\":%q,\"metadata\":{\"alias\":%t,\"max_age\":%d%s}}", p.s, v.Origin, v.Target, v.Alias, int(max_age.Seconds()), fallback)
 	p.s = ",\n"
 	return err
 }
@@ -136,3 +155,175 @@ func (p *TreePrinter) Flush() error {
 
 	return nil
 }
+
+// InstallScriptRenderer renders install scripts of each version of apps
+// as a tar archive of "{path}/{name}@{version}/install.{sh,ps1}" files.
+type InstallScriptRenderer struct {
+	w *tar.Writer
+
+	table PlatformTable
+	app   string
+	// For each version including aliases.
+	items map[string][]Item
+}
+
+func NewInstallScriptRenderer(w io.Writer) *InstallScriptRenderer {
+	return &InstallScriptRenderer{
+		w:     tar.NewWriter(w),
+		items: map[string][]Item{},
+	}
+}
+
+func (p *InstallScriptRenderer) Render(c Config, v Item) error {
+	app, version, ok := strings.Cut(v.Origin, "@")
+	if !ok {
+		return fmt.Errorf("invalid origin: %q", v.Origin)
+	}
+	version, _, _ = strings.Cut(version, "/")
+
+	p.table = c.table()
+	if p.app != app {
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.app = app
+	}
+
+	p.items[version] = append(p.items[version], v)
+	return nil
+}
+
+func (p *InstallScriptRenderer) flush() error {
+	items := p.items
+	p.items = map[string][]Item{}
+
+	versions := slices.Sorted(maps.Keys(items))
+	for _, version := range versions {
+		for _, name := range []string{"install.sh", "install.ps1"} {
+			b := &bytes.Buffer{}
+			if err := WriteInstallScript(b, p.table, name, items[version]); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return fmt.Errorf("write %s of %s@%s: %w", name, p.app, version, err)
+			}
+
+			if err := p.w.WriteHeader(&tar.Header{
+				Name: p.app + "@" + version + "/" + name,
+				Mode: 0o755,
+				Size: int64(b.Len()),
+			}); err != nil {
+				return err
+			}
+			if _, err := p.w.Write(b.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *InstallScriptRenderer) Flush() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	return p.w.Close()
+}
+
+// IndexRenderer renders the listings served by [ServerConfig]
+// as a tar archive of "index.json" files:
+//
+//	{dir}/index.json                  [AppListing] of apps under the dir
+//	{path}/{name}/index.json          [VersionListing] of the app
+//	{path}/{name}@{version}/index.json [PlatformListing] of the version
+type IndexRenderer struct {
+	w *tar.Writer
+
+	table PlatformTable
+	apps  []AppInfo
+	// For each app.
+	versions map[AppInfo][]Version
+	// For each origin without platform.
+	items map[string][]Item
+}
+
+func NewIndexRenderer(w io.Writer) *IndexRenderer {
+	return &IndexRenderer{
+		w:        tar.NewWriter(w),
+		versions: map[AppInfo][]Version{},
+		items:    map[string][]Item{},
+	}
+}
+
+func (p *IndexRenderer) Render(c Config, v Item) error {
+	p.table = c.table()
+
+	app := AppInfo{Path: strings.Trim(v.Path, "/"), Name: v.Name}
+	versions, ok := p.versions[app]
+	if !ok {
+		p.apps = append(p.apps, app)
+	}
+	if !slices.Contains(versions, v.Version) {
+		p.versions[app] = append(versions, v.Version)
+	}
+
+	// Origin without platform.
+	i := strings.LastIndex(v.Origin, "@")
+	j := strings.Index(v.Origin[max(i, 0):], "/")
+	if i < 0 || j < 0 {
+		return fmt.Errorf("invalid origin: %q", v.Origin)
+	}
+
+	k := v.Origin[:i+j]
+	p.items[k] = append(p.items[k], v)
+	return nil
+}
+
+func (p *IndexRenderer) Flush() error {
+	files := map[string]any{}
+
+	dirs := []string{""}
+	for _, app := range p.apps {
+		for dir := app.Path; dir != ""; dir = path.Dir(dir) {
+			if dir == "." || slices.Contains(dirs, dir) {
+				break
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range dirs {
+		files[path.Join(dir, "index.json")] = NewAppListing(p.apps, dir)
+	}
+	for _, app := range p.apps {
+		files[path.Join(app.Path, app.Name, "index.json")] = NewVersionListing(app.Path, app.Name, p.versions[app])
+	}
+	for k, items := range p.items {
+		v, err := NewPlatformListing(p.table, items)
+		if err != nil {
+			return fmt.Errorf("list platforms of %s: %w", k, err)
+		}
+		files[path.Join(k, "index.json")] = v
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		data, err := json.MarshalIndent(files[name], "", "\t")
+		if err != nil {
+			return fmt.Errorf("marshal %s: %w", name, err)
+		}
+		data = append(data, '\n')
+		if err := p.w.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o644,
+			Size: int64(len(data)),
+		}); err != nil {
+			return err
+		}
+		if _, err := p.w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return p.w.Close()
+}
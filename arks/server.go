@@ -1,36 +1,423 @@
 package arks
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 )
 
 type ServerConfig struct {
 	Querier
+
+	// Stripped from the request path.
+	Prefix string
+	// Origins allowed for CORS, or "*" for any.
+	AllowOrigins []string
 }
 
+const allowedMethods = "GET, HEAD, OPTIONS"
+
 func (c *ServerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	c.cors(w, r)
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		c.preflight(w, r)
+		return
+	default:
+		w.Header().Set("Allow", allowedMethods)
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	item, err := ParseItem(r.URL.Path)
+	p, ok := strings.CutPrefix(r.URL.Path, strings.TrimSuffix(c.Prefix, "/"))
+	if !ok || (p != "" && p[0] != '/') {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !strings.Contains(p, "@") {
+		c.listApp(w, r, p)
+		return
+	}
+
+	item, err := ParseItem(p)
+	if err != nil {
+		c.notFound(w, r, fmt.Errorf("invalid item: %w", err))
+		return
+	}
+	if IsInstallScript(string(item.Platform)) {
+		c.installScript(w, r, item)
+		return
+	}
+	if item.Platform == "" {
+		w.Header().Add("Vary", "Accept")
+		if strings.HasSuffix(p, "/") || acceptsJson(r) {
+			c.listPlatforms(w, r, item)
+			return
+		}
+	}
+	if os, arch, _ := item.Platform.Split(); os == "" || arch == "" {
+		w.Header().Add("Vary", "User-Agent, X-Arks-Os, X-Arks-Arch")
+		item.Platform = inferPlatform(c.table(), r, item.Platform)
+	}
+	if libc := Libc(r.URL.Query().Get("libc")); libc != "" {
+		libc = libc.Normalized()
+		if os_ := item.Platform.Os(); os_ != "" {
+			spec, _ := c.table().Spec(os_)
+			if !slices.Contains(spec.Libcs, libc) {
+				c.notFound(w, r, fmt.Errorf("libc %q is not known on %s: %w", libc, os_, os.ErrNotExist))
+				return
+			}
+		}
+		item.Platform = item.Platform.WithLibc(libc)
+	}
+
+	res, err := c.Query(r.Context(), item)
 	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+	if p := pickedPlatform(c.table(), res); p != "" {
+		w.Header().Set("X-Arks-Platform", string(p))
+	}
+
+	c.redirect(w, r, res)
+}
+
+func (c *ServerConfig) installScript(w http.ResponseWriter, r *http.Request, item Item) {
+	q, ok := c.Querier.(AppQuerier)
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	target, err := c.Query(r.Context(), item)
-	if err == nil {
-		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	conf, app, err := q.QueryApp(r.Context(), item)
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	items, err := conf.BuildVersion(app, item.Version.String())
+	if err != nil {
+		c.fail(w, r, err)
 		return
 	}
-	if errors.Is(err, os.ErrNotExist) {
+
+	b := &strings.Builder{}
+	if err := WriteInstallScript(b, c.table(), string(item.Platform), items); err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Cache-Control", cacheControl(conf.Cache, items[0].Alias))
+	h.Set("ETag", etagOf(b.String()))
+	if matchEtag(r.Header.Get("If-None-Match"), h.Get("ETag")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	io.WriteString(w, b.String())
+}
+
+// listApp responds the versions of the app, or the apps under the path.
+func (c *ServerConfig) listApp(w http.ResponseWriter, r *http.Request, p string) {
+	q, ok := c.Querier.(AppQuerier)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	p = strings.Trim(p, "/")
+	if i := strings.LastIndex(p, "/"); i > 0 {
+		conf, app, err := q.QueryApp(r.Context(), Item{Path: p[:i], Name: p[i+1:]})
+		if err == nil {
+			c.json(w, r, conf.Cache, NewVersionListing(p[:i], app.Name, app.Versions))
+			return
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			c.fail(w, r, err)
+			return
+		}
+	}
+
+	entries, err := q.ListApps(r.Context())
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	apps := make([]AppInfo, 0, len(entries))
+	for _, entry := range entries {
+		apps = append(apps, AppInfo{
+			Path: strings.Trim(entry.Config.Path, "/"),
+			Name: entry.App.Name,
+		})
+	}
+
+	v := NewAppListing(apps, p)
+	if len(v.Apps) == 0 {
+		c.notFound(w, r, &AppNotFoundError{App: p})
+		return
+	}
+
+	c.json(w, r, NewConfig().Cache, v)
+}
+
+func (c *ServerConfig) listPlatforms(w http.ResponseWriter, r *http.Request, item Item) {
+	q, ok := c.Querier.(AppQuerier)
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
 
+	conf, app, err := q.QueryApp(r.Context(), item)
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	items, err := conf.BuildVersion(app, item.Version.String())
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	v, err := NewPlatformListing(c.table(), items)
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	c.json(w, r, conf.Cache, v)
+}
+
+// Listings are cached as long as aliases are.
+func (c *ServerConfig) json(w http.ResponseWriter, r *http.Request, cache CacheConfig, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		c.fail(w, r, err)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cache.Alias.Seconds())))
+	h.Set("ETag", etagOf(string(data)))
+	if matchEtag(r.Header.Get("If-None-Match"), h.Get("ETag")) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(data)
+}
+
+func acceptsJson(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func (c *ServerConfig) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		c.notFound(w, r, err)
+		return
+	}
+
 	http.Error(w, "internal server error", http.StatusInternalServerError)
 }
+
+func (c *ServerConfig) cors(w http.ResponseWriter, r *http.Request) {
+	if len(c.AllowOrigins) == 0 {
+		return
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	if slices.Contains(c.AllowOrigins, "*") {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else if slices.Contains(c.AllowOrigins, origin) {
+		h.Set("Access-Control-Allow-Origin", origin)
+	} else {
+		return
+	}
+	h.Set("Access-Control-Expose-Headers", "Location, ETag, X-Arks-Platform, X-Arks-Fallback")
+}
+
+func (c *ServerConfig) preflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("Allow", allowedMethods)
+	if h.Get("Access-Control-Allow-Origin") != "" {
+		h.Set("Access-Control-Allow-Methods", allowedMethods)
+		if v := r.Header.Get("Access-Control-Request-Headers"); v != "" {
+			h.Set("Access-Control-Allow-Headers", v)
+		}
+		h.Set("Access-Control-Max-Age", "86400")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *ServerConfig) redirect(w http.ResponseWriter, r *http.Request, res Result) {
+	h := w.Header()
+	h.Set("Cache-Control", cacheControl(res.Cache, res.Alias))
+
+	if res.Fallback != "" {
+		h.Set("X-Arks-Fallback", string(res.Fallback))
+	}
+
+	etag := etagOf(res.Target)
+	h.Set("ETag", etag)
+	if matchEtag(r.Header.Get("If-None-Match"), etag) {
+		h.Set("Location", res.Target)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if res.Alias {
+		http.Redirect(w, r, res.Target, http.StatusTemporaryRedirect)
+	} else {
+		http.Redirect(w, r, res.Target, http.StatusPermanentRedirect)
+	}
+}
+
+func (c *ServerConfig) table() PlatformTable {
+	if q, ok := c.Querier.(interface{ Table() PlatformTable }); ok {
+		return q.Table()
+	}
+	return DefaultPlatformTable()
+}
+
+func cacheControl(c CacheConfig, alias bool) string {
+	if alias {
+		return fmt.Sprintf("public, max-age=%d", int(c.Alias.Seconds()))
+	}
+	return fmt.Sprintf("public, max-age=%d, immutable", int(c.Pinned.Seconds()))
+}
+
+// pickedPlatform returns the normalized platform the result is built for.
+func pickedPlatform(t PlatformTable, res Result) Platform {
+	p := res.Fallback
+	if p == "" {
+		request, err := ParseItem("/" + res.Origin)
+		if err != nil {
+			return ""
+		}
+		p = request.Platform
+	}
+
+	p = t.Normalize(p)
+	os, arch, variant := p.Split()
+	libc := p.Libc()
+	if _, _, v := res.Pattern.Split(); !strings.HasPrefix(string(v), "_") {
+		if v := Variant(strings.Trim(string(v), "/")); v != "" {
+			variant = v
+		}
+	}
+	switch v := res.Pattern.Libc().Normalized(); v {
+	case "_":
+	case "":
+		// A pattern without a libc is built for the default one.
+		if libc != "" {
+			libc = t.DefaultLibc(os)
+		}
+	default:
+		libc = v
+	}
+
+	return t.Normalize(Platform(string(os) + "/" + string(arch) + "/" + string(variant)).WithLibc(libc))
+}
+
+func etagOf(target string) string {
+	d := sha256.Sum256([]byte(target))
+	return `"` + hex.EncodeToString(d[:16]) + `"`
+}
+
+func matchEtag(v string, etag string) bool {
+	for e := range strings.SplitSeq(v, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" {
+			return true
+		}
+		if strings.TrimPrefix(e, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type notFoundBody struct {
+	Error       string     `json:"error"`
+	Suggestions []string   `json:"suggestions,omitempty"`
+	Versions    []string   `json:"versions,omitempty"`
+	Platforms   []Platform `json:"platforms,omitempty"`
+}
+
+// notFound responds 404 with the alternatives carried by the error.
+func (c *ServerConfig) notFound(w http.ResponseWriter, r *http.Request, err error) {
+	body := notFoundBody{Error: err.Error()}
+
+	var (
+		err_app      *AppNotFoundError
+		err_version  *VersionNotFoundError
+		err_platform *PlatformNotSupportedError
+	)
+	switch {
+	case errors.As(err, &err_app):
+		body.Suggestions = err_app.Suggestions
+	case errors.As(err, &err_version):
+		body.Versions = make([]string, 0, len(err_version.Versions))
+		for _, v := range err_version.Versions {
+			body.Versions = append(body.Versions, strings.Join(strings.Fields(string(v)), " "))
+		}
+	case errors.As(err, &err_platform):
+		body.Platforms = err_platform.Platforms
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if acceptsJson(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n", body.Error)
+	if len(body.Suggestions) > 0 {
+		fmt.Fprintf(b, "\ndid you mean:\n")
+		for _, v := range body.Suggestions {
+			fmt.Fprintf(b, "\t%s\n", v)
+		}
+	}
+	if len(body.Versions) > 0 {
+		fmt.Fprintf(b, "\navailable versions:\n")
+		for _, v := range body.Versions {
+			fmt.Fprintf(b, "\t%s\n", v)
+		}
+	}
+	if len(body.Platforms) > 0 {
+		fmt.Fprintf(b, "\nsupported platforms:\n")
+		for _, v := range body.Platforms {
+			fmt.Fprintf(b, "\t%s\n", v)
+		}
+	}
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,403 @@
+package arks_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	q, err := arks.NewIndexQuerier(testPort())
+	require.NoError(t, err)
+
+	s := &arks.ServerConfig{
+		Querier:      q,
+		Prefix:       "/pkg/",
+		AllowOrigins: []string{"https://example.com"},
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil))
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+		require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64", w.Header().Get("Location"))
+		require.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	})
+	t.Run("redirect by alias", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@latest/linux/x86_64", nil))
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+		require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64", w.Header().Get("Location"))
+		require.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	})
+	t.Run("out of prefix", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lesomnus/arrakis/arks@latest/linux/x86_64", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+	t.Run("version not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.3/linux/x86_64", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Contains(t, w.Body.String(), "0.0.2 latest")
+	})
+	t.Run("platform not supported in JSON", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@latest/darwin/arm64", nil)
+		r.Header.Set("Accept", "application/json")
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		body := struct {
+			Platforms []string
+		}{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		require.Equal(t, []string{"linux/amd64", "linux/arm64"}, body.Platforms)
+	})
+	t.Run("did you mean", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/protocolbuffers/protobuf/protocc@latest/linux/x86_64", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Contains(t, w.Body.String(), "protocolbuffers/protobuf/protoc\n")
+	})
+	t.Run("head", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil))
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+		require.NotEmpty(t, w.Header().Get("Location"))
+	})
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+	})
+	t.Run("not modified", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil))
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		r := httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil)
+		r.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotModified, w.Code)
+
+		// Different target has different ETag.
+		r = httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.1/linux/x86_64", nil)
+		r.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+	})
+	t.Run("preflight", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil)
+		r.Header.Set("Origin", "https://example.com")
+		r.Header.Set("Access-Control-Request-Method", "GET")
+		r.Header.Set("Access-Control-Request-Headers", "X-Foo")
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "X-Foo", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+	t.Run("disallowed origin", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/x86_64", nil)
+		r.Header.Set("Origin", "https://evil.example.com")
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("inferred platform", func(t *testing.T) {
+		tcs := []struct {
+			desc     string
+			path     string
+			header   http.Header
+			platform string
+			target   string
+		}{
+			{
+				desc:     "query",
+				path:     "/pkg/lesomnus/arrakis/arks@0.0.2?os=linux&arch=aarch64",
+				platform: "linux/arm64",
+				target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64",
+			},
+			{
+				desc:     "header",
+				path:     "/pkg/lesomnus/arrakis/arks@0.0.2",
+				header:   http.Header{"X-Arks-Os": {"linux"}, "X-Arks-Arch": {"x86_64"}},
+				platform: "linux/amd64",
+				target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64",
+			},
+			{
+				desc:     "arch only",
+				path:     "/pkg/lesomnus/arrakis/arks@0.0.2/linux/",
+				header:   http.Header{"X-Arks-Arch": {"arm64"}},
+				platform: "linux/arm64",
+				target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64",
+			},
+			{
+				desc:     "libc",
+				path:     "/pkg/lesomnus/arrakis/arks@0.0.2?os=linux&arch=x86_64&libc=musl",
+				platform: "linux/amd64/glibc",
+				target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64",
+			},
+			{
+				desc:     "browser",
+				path:     "/pkg/protocolbuffers/protobuf/protoc@33.4",
+				header:   http.Header{"User-Agent": {"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"}},
+				platform: "windows/amd64",
+				target:   "https://github.com/protocolbuffers/protobuf/releases/download/v33.4/protoc-33.4-win64.zip",
+			},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.desc, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+				for k, vs := range tc.header {
+					r.Header[k] = vs
+				}
+
+				w := httptest.NewRecorder()
+				s.ServeHTTP(w, r)
+				require.Equal(t, http.StatusPermanentRedirect, w.Code)
+				require.Equal(t, tc.target, w.Header().Get("Location"))
+				require.Equal(t, tc.platform, w.Header().Get("X-Arks-Platform"))
+				require.Contains(t, w.Header().Values("Vary"), "User-Agent, X-Arks-Os, X-Arks-Arch")
+			})
+		}
+	})
+	t.Run("picked platform", func(t *testing.T) {
+		tcs := []struct {
+			desc     string
+			path     string
+			target   string
+			platform string
+			fallback string
+		}{
+			{
+				desc:     "fallback",
+				path:     "/pkg/example.com/fallback@1.0/darwin/arm64",
+				target:   "https://example.com/dl/1.0/fallback-darwin-amd64",
+				platform: "darwin/amd64",
+				fallback: "darwin/amd64",
+			},
+			{
+				desc:     "no fallback",
+				path:     "/pkg/example.com/fallback@1.0/darwin/amd64",
+				target:   "https://example.com/dl/1.0/fallback-darwin-amd64",
+				platform: "darwin/amd64",
+			},
+			{
+				desc:     "variant",
+				path:     "/pkg/example.com/variant@1.0/linux/armv8l",
+				target:   "https://example.com/dl/1.0/variant-arm-v7",
+				platform: "linux/arm/v7",
+			},
+			{
+				desc:     "libc",
+				path:     "/pkg/example.com/libc@1.0/linux/amd64?libc=musl",
+				target:   "https://example.com/dl/1.0/libc-amd64-musl",
+				platform: "linux/amd64/musl",
+			},
+		}
+		for _, tc := range tcs {
+			t.Run(tc.desc, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+				require.Equal(t, http.StatusPermanentRedirect, w.Code)
+				require.Equal(t, tc.target, w.Header().Get("Location"))
+				require.Equal(t, tc.platform, w.Header().Get("X-Arks-Platform"))
+				require.Equal(t, tc.fallback, w.Header().Get("X-Arks-Fallback"))
+			})
+		}
+	})
+	t.Run("unknown libc", func(t *testing.T) {
+		for _, p := range []string{
+			"/pkg/lesomnus/arrakis/arks@0.0.2?os=linux&arch=x86_64&libc=uclibc",
+			"/pkg/lesomnus/arrakis/arks@0.0.2?os=linux&arch=x86_64&libc=_",
+			"/pkg/lesomnus/arrakis/arks@0.0.2/linux/amd64?libc=uclibc",
+			"/pkg/protocolbuffers/protobuf/protoc@33.4/windows/amd64?libc=musl",
+		} {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+			require.Equal(t, http.StatusNotFound, w.Code, p)
+			require.Contains(t, w.Body.String(), "is not known on", p)
+		}
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2/linux/amd64?libc=GNU", nil))
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+		require.Equal(t, "linux/amd64/glibc", w.Header().Get("X-Arks-Platform"))
+	})
+	t.Run("platform not inferred", func(t *testing.T) {
+		for _, ua := range []string{
+			"curl/8.5.0",
+			// Only the OS is told.
+			"Wget/1.21.4 (linux-gnu)",
+			"Mozilla/5.0 (Windows NT 10.0; Microsoft Windows 10.0.22631; en-US) PowerShell/7.4.6",
+		} {
+			r := httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@0.0.2", nil)
+			r.Header.Set("User-Agent", ua)
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			require.Equal(t, http.StatusNotFound, w.Code, ua)
+			require.Contains(t, w.Body.String(), "platform is not specified", ua)
+			require.Contains(t, w.Body.String(), "linux/amd64", ua)
+			require.Empty(t, w.Header().Get("X-Arks-Platform"), ua)
+		}
+	})
+	t.Run("install script", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@latest/install.sh", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		require.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+
+		body := w.Body.String()
+		require.Contains(t, body, "'linux/amd64') url='https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64'; sum='https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64.sha256' ;;")
+		require.Contains(t, body, "'linux/arm64') url=")
+		require.NotContains(t, body, "v0.0.1")
+	})
+	t.Run("install script for PowerShell", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/protocolbuffers/protobuf/protoc@33.4/install.ps1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+		require.Contains(t, w.Body.String(), "'amd64' = @('https://github.com/protocolbuffers/protobuf/releases/download/v33.4/protoc-33.4-win64.zip', '')")
+		require.Contains(t, w.Body.String(), `throw "protoc 33.4 is not available for windows/$arch; available platforms: windows/amd64"`)
+	})
+	t.Run("install script escape", func(t *testing.T) {
+		port := testPort()
+		port["github.com/protocolbuffers/protobuf/protoc/versions"] = &fstest.MapFile{Data: []byte("1.0-`$(x)\"\n")}
+		s := &arks.ServerConfig{Querier: arks.FsQuerier{FS: port}}
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protocolbuffers/protobuf/protoc@1.0-%60$(x)%22/install.ps1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "throw \"protoc 1.0-```$(x)`\" is not available")
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protocolbuffers/protobuf/protoc@1.0-%60$(x)%22/install.sh", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "version='1.0-`$(x)\"'")
+	})
+	t.Run("install script for no platforms", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/lesomnus/arrakis/arks@latest/install.ps1", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+	t.Run("list", func(t *testing.T) {
+		get := func(t *testing.T, p string, v any) {
+			r := httptest.NewRequest(http.MethodGet, p, nil)
+			r.Header.Set("Accept", "application/json")
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(w.Body).Decode(v))
+		}
+
+		t.Run("apps", func(t *testing.T) {
+			v := arks.AppListing{}
+			get(t, "/pkg/", &v)
+			require.Equal(t, []arks.AppInfo{
+				{Path: "example.com", Name: "fallback"},
+				{Path: "example.com", Name: "libc"},
+				{Path: "example.com", Name: "nofallback"},
+				{Path: "example.com", Name: "optin"},
+				{Path: "example.com", Name: "optout"},
+				{Path: "example.com", Name: "variant"},
+				{Path: "lesomnus/arrakis", Name: "arks"},
+				{Path: "protocolbuffers/protobuf", Name: "protoc"},
+			}, v.Apps)
+
+			v = arks.AppListing{}
+			get(t, "/pkg/protocolbuffers", &v)
+			require.Equal(t, []arks.AppInfo{
+				{Path: "protocolbuffers/protobuf", Name: "protoc"},
+			}, v.Apps)
+		})
+		t.Run("versions", func(t *testing.T) {
+			v := arks.VersionListing{}
+			get(t, "/pkg/lesomnus/arrakis/arks", &v)
+			require.Equal(t, []arks.VersionInfo{
+				{Version: "0.0.1", Aliases: []string{}},
+				{Version: "0.0.2", Aliases: []string{"latest"}},
+			}, v.Versions)
+		})
+		t.Run("platforms", func(t *testing.T) {
+			v := arks.PlatformListing{}
+			get(t, "/pkg/lesomnus/arrakis/arks@latest/", &v)
+			require.Equal(t, "0.0.2", v.Version)
+			require.Equal(t, []string{"latest"}, v.Aliases)
+			require.Equal(t, []arks.PlatformInfo{
+				{
+					Platform: "linux/amd64",
+					Target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64",
+					Checksum: "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64.sha256",
+				},
+				{
+					Platform: "linux/arm64",
+					Target:   "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64",
+					Checksum: "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-arm64.sha256",
+				},
+			}, v.Platforms)
+		})
+		t.Run("unknown", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pkg/foo/bar", nil))
+			require.Equal(t, http.StatusNotFound, w.Code)
+		})
+	})
+	t.Run("reloaded table", func(t *testing.T) {
+		port := testPort()
+		q, err := arks.NewReloadQuerier(port)
+		require.NoError(t, err)
+
+		s := &arks.ServerConfig{Querier: q}
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lesomnus/arrakis/arks@0.0.2/linux/x86-64", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		port["config.yaml"] = &fstest.MapFile{Data: []byte(`
+oses:
+  linux:
+    archs:
+      amd64: [x86-64]
+`)}
+		ok, err := q.Reload(false)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lesomnus/arrakis/arks@0.0.2/linux/x86-64", nil))
+		require.Equal(t, http.StatusPermanentRedirect, w.Code)
+		require.Equal(t, "https://github.com/lesomnus/arrakis/releases/download/v0.0.2/arks-linux-amd64", w.Header().Get("Location"))
+		require.Equal(t, "linux/amd64", w.Header().Get("X-Arks-Platform"))
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lesomnus/arrakis/arks@0.0.2/", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		v := arks.PlatformListing{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v))
+		require.Len(t, v.Platforms, 2)
+		require.Equal(t, arks.Platform("linux/amd64"), v.Platforms[0].Platform)
+		require.Equal(t, arks.Platform("linux/arm64"), v.Platforms[1].Platform)
+	})
+}
@@ -0,0 +1,309 @@
+package arks
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PlatformTable lists the known OSes and how they spell their architectures.
+type PlatformTable []OsSpec
+
+type OsSpec struct {
+	Os      Os
+	Aliases []Os
+	// In the order they are expanded.
+	Archs []ArchSpelling
+	// Spellings that are normalized but not expanded.
+	Dialects []ArchSpelling
+	// Expanded only for apps opting in to them.
+	OptIn []Arch
+	// Not matched by the "_" wildcard.
+	Explicit bool
+	// The first one is the default.
+	Libcs []Libc
+}
+
+type ArchSpelling struct {
+	Arch       Arch
+	Normalized Arch
+	// Implied by the spelling, e.g. "v7" of "armv7l".
+	Variant Variant
+}
+
+type OsConfig struct {
+	Aliases []Os
+	// Normalized architecture -> Spellings
+	Archs map[Arch][]Arch
+	// Normalized architecture with an optional variant, e.g. "arm/v7" -> Spellings
+	Dialects map[string][]Arch
+}
+
+func DefaultPlatformTable() PlatformTable {
+	return PlatformTable{
+		{
+			Os: OsLinux,
+			Archs: []ArchSpelling{
+				{"x86", ArchX86, ""},
+				{"x86_64", ArchAmd64, ""},
+				{"aarch32", ArchArm, ""},
+				{"aarch64", ArchArm64, ""},
+				{"amd64", ArchAmd64, ""},
+				{"arm64", ArchArm64, ""},
+				{"riscv64", ArchRiscv64, ""},
+				{"ppc64le", ArchPpc64le, ""},
+				{"s390x", ArchS390x, ""},
+				{"loong64", ArchLoong64, ""},
+				{"mips64le", ArchMips64le, ""},
+			},
+			Dialects: []ArchSpelling{
+				// uname -m
+				{"i386", ArchX86, ""},
+				{"i486", ArchX86, ""},
+				{"i586", ArchX86, ""},
+				{"i686", ArchX86, ""},
+				{"armv6l", ArchArm, VariantArmV6},
+				{"armv7l", ArchArm, VariantArmV7},
+				{"armv8l", ArchArm, VariantArmV8},
+				{"loongarch64", ArchLoong64, ""},
+				// Docker
+				{"386", ArchX86, ""},
+				{"arm", ArchArm, ""},
+				// Debian
+				{"armel", ArchArm, VariantArmV6},
+				{"armhf", ArchArm, VariantArmV7},
+				{"mips64el", ArchMips64le, ""},
+				{"ppc64el", ArchPpc64le, ""},
+			},
+			OptIn: []Arch{ArchRiscv64, ArchPpc64le, ArchS390x, ArchLoong64, ArchMips64le},
+			Libcs: []Libc{LibcGlibc, LibcMusl},
+		},
+		{
+			Os:      OsWindows,
+			Aliases: []Os{"windows_nt", "win"},
+			Archs: []ArchSpelling{
+				{"AMD64", ArchAmd64, ""},
+				{"x86", ArchX86, ""},
+				{"ARM64", ArchArm64, ""},
+				{"ARM", ArchArm, ""},
+			},
+			Dialects: []ArchSpelling{
+				{"x64", ArchAmd64, ""},
+				{"386", ArchX86, ""},
+			},
+		},
+		{
+			Os:      OsDarwin,
+			Aliases: []Os{"macos", "osx"},
+			Archs: []ArchSpelling{
+				{"x86_64", ArchAmd64, ""},
+				{"arm64", ArchArm64, ""},
+			},
+		},
+		{
+			Os: OsFreeBSD,
+			Archs: []ArchSpelling{
+				{"amd64", ArchAmd64, ""},
+				{"i386", ArchX86, ""},
+				{"arm64", ArchArm64, ""},
+				{"riscv64", ArchRiscv64, ""},
+			},
+			OptIn:    []Arch{ArchRiscv64},
+			Explicit: true,
+		},
+		{
+			Os: OsOpenBSD,
+			Archs: []ArchSpelling{
+				{"amd64", ArchAmd64, ""},
+				{"i386", ArchX86, ""},
+				{"arm64", ArchArm64, ""},
+				{"riscv64", ArchRiscv64, ""},
+			},
+			OptIn:    []Arch{ArchRiscv64},
+			Explicit: true,
+		},
+		{
+			Os: OsNetBSD,
+			Archs: []ArchSpelling{
+				{"amd64", ArchAmd64, ""},
+				{"i386", ArchX86, ""},
+				{"arm64", ArchArm64, ""},
+			},
+			Explicit: true,
+		},
+		{
+			Os: OsAndroid,
+			Archs: []ArchSpelling{
+				{"x86_64", ArchAmd64, ""},
+				{"aarch64", ArchArm64, ""},
+				{"arm", ArchArm, ""},
+			},
+			Dialects: []ArchSpelling{
+				// ABIs
+				{"arm64-v8a", ArchArm64, ""},
+				{"armeabi-v7a", ArchArm, VariantArmV7},
+			},
+			Explicit: true,
+		},
+		{
+			Os: OsIllumos,
+			Archs: []ArchSpelling{
+				{"amd64", ArchAmd64, ""},
+			},
+			Dialects: []ArchSpelling{
+				// uname -m
+				{"i86pc", ArchAmd64, ""},
+			},
+			Explicit: true,
+		},
+	}
+}
+
+// LoadPlatformTable returns the default table merged with the root config of the port.
+func LoadPlatformTable(fs fs.FS) (PlatformTable, error) {
+	c, err := ReadConfigFile(fs, "config.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	return DefaultPlatformTable().Merge(c.Oses), nil
+}
+
+func (t PlatformTable) Merge(oses map[Os]OsConfig) PlatformTable {
+	t_ := make(PlatformTable, len(t))
+	for i, spec := range t {
+		spec.Aliases = slices.Clone(spec.Aliases)
+		spec.Archs = slices.Clone(spec.Archs)
+		spec.Dialects = slices.Clone(spec.Dialects)
+		spec.OptIn = slices.Clone(spec.OptIn)
+		spec.Libcs = slices.Clone(spec.Libcs)
+		t_[i] = spec
+	}
+
+	for _, os := range slices.Sorted(maps.Keys(oses)) {
+		c := oses[os]
+		i := t_.index(os)
+		if i < 0 {
+			t_ = append(t_, OsSpec{Os: os})
+			i = len(t_) - 1
+		}
+
+		spec := &t_[i]
+		for _, alias := range c.Aliases {
+			if alias != spec.Os && !slices.Contains(spec.Aliases, alias) {
+				spec.Aliases = append(spec.Aliases, alias)
+			}
+		}
+		for _, arch := range slices.Sorted(maps.Keys(c.Archs)) {
+			spellings := c.Archs[arch]
+			if len(spellings) == 0 {
+				spellings = []Arch{arch}
+			}
+			for _, spelling := range spellings {
+				spec.Archs = mergeSpelling(spec.Archs, ArchSpelling{spelling, arch, ""})
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(c.Dialects)) {
+			arch, variant, _ := strings.Cut(k, "/")
+			for _, spelling := range c.Dialects[k] {
+				spec.Dialects = mergeSpelling(spec.Dialects, ArchSpelling{spelling, Arch(arch), Variant(variant)})
+			}
+		}
+	}
+
+	return t_
+}
+
+func mergeSpelling(vs []ArchSpelling, v ArchSpelling) []ArchSpelling {
+	i := slices.IndexFunc(vs, func(v_ ArchSpelling) bool { return strings.EqualFold(string(v_.Arch), string(v.Arch)) })
+	if i < 0 {
+		return append(vs, v)
+	}
+
+	vs[i] = v
+	return vs
+}
+
+func (t PlatformTable) index(os Os) int {
+	eq := func(v Os) bool { return strings.EqualFold(string(v), string(os)) }
+	return slices.IndexFunc(t, func(v OsSpec) bool {
+		return eq(v.Os) || slices.ContainsFunc(v.Aliases, eq)
+	})
+}
+
+func (t PlatformTable) Oses() []Os {
+	vs := make([]Os, 0, len(t))
+	for _, spec := range t {
+		if spec.Explicit {
+			continue
+		}
+		vs = append(vs, spec.Os)
+	}
+	return vs
+}
+
+func (t PlatformTable) Spec(os Os) (OsSpec, bool) {
+	i := t.index(os)
+	if i < 0 {
+		return OsSpec{}, false
+	}
+	return t[i], true
+}
+
+func (t PlatformTable) NormalizeOs(os Os) Os {
+	if spec, ok := t.Spec(os); ok {
+		return spec.Os
+	}
+	return Os(strings.ToLower(string(os)))
+}
+
+// NormalizeArch also looks up spellings of the other OSes if the OS does not know the spelling.
+func (t PlatformTable) NormalizeArch(os Os, arch Arch) (Arch, Variant) {
+	find := func(vs []ArchSpelling) (ArchSpelling, bool) {
+		i := slices.IndexFunc(vs, func(v ArchSpelling) bool { return strings.EqualFold(string(v.Arch), string(arch)) })
+		if i < 0 {
+			return ArchSpelling{}, false
+		}
+		return vs[i], true
+	}
+
+	specs := slices.Clone(t)
+	if i := t.index(os); i >= 0 {
+		specs = slices.Insert(specs, 0, t[i])
+	}
+	for _, spec := range specs {
+		if v, ok := find(spec.Archs); ok {
+			return v.Normalized, v.Variant
+		}
+		if v, ok := find(spec.Dialects); ok {
+			return v.Normalized, v.Variant
+		}
+	}
+
+	return Arch(strings.ToLower(string(arch))), ""
+}
+
+func (t PlatformTable) DefaultLibc(os Os) Libc {
+	spec, ok := t.Spec(os)
+	if !ok || len(spec.Libcs) == 0 {
+		return ""
+	}
+	return spec.Libcs[0]
+}
+
+func (t PlatformTable) Knows(os Os, arch Arch, optin ...Arch) bool {
+	spec, ok := t.Spec(os)
+	if !ok {
+		return false
+	}
+	if !spec.Expands(arch, optin...) {
+		return false
+	}
+	return slices.ContainsFunc(spec.Archs, func(v ArchSpelling) bool { return v.Normalized == arch })
+}
+
+func (s OsSpec) Expands(arch Arch, optin ...Arch) bool {
+	return !slices.Contains(s.OptIn, arch) || slices.Contains(optin, arch)
+}
@@ -0,0 +1,111 @@
+package arks_test
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformTable(t *testing.T) {
+	port := fstest.MapFS{
+		"config.yaml": &fstest.MapFile{Data: []byte(`
+oses:
+  linux:
+    archs:
+      x86: [i686, i386]
+      arm: [armv7l]
+  windows:
+    archs:
+      amd64: [x64]
+  darwin:
+    aliases: [macos]
+  dragonfly:
+    archs:
+      amd64: []
+      arm64: [aarch64]
+`)},
+	}
+
+	table, err := arks.LoadPlatformTable(port)
+	require.NoError(t, err)
+	require.Equal(t, []arks.Os{"linux", "windows", "darwin", "dragonfly"}, table.Oses())
+
+	t.Run("Normalize", func(t *testing.T) {
+		tests := [][]arks.Platform{
+			{"linux/i686", "linux/x86"},
+			{"linux/armv7l/v7", "linux/arm/v7"},
+			{"linux/x86_64", "linux/amd64"},
+			{"windows/x64", "windows/amd64"},
+			{"macos/arm64", "darwin/arm64"},
+			{"dragonfly/aarch64", "dragonfly/arm64"},
+			{"plan9/i686", "plan9/x86"},
+			{"plan9/mips", "plan9/mips"},
+		}
+		for _, test := range tests {
+			require.Equal(t, test[1], table.Normalize(test[0]), test[0])
+		}
+	})
+	t.Run("Expand", func(t *testing.T) {
+		tests := [][]arks.Platform{
+			{"linux/_32/",
+				"linux/x86/",
+				"linux/aarch32/",
+				"linux/armv7l/",
+				"linux/i686/",
+				"linux/i386/",
+			},
+			{"windows/_amd64/",
+				"windows/AMD64/",
+				"windows/x64/",
+			},
+			{"macos/_/",
+				"darwin/x86_64/",
+				"darwin/arm64/",
+			},
+			{"dragonfly/_/",
+				"dragonfly/amd64/",
+				"dragonfly/aarch64/",
+			},
+			{"_/_arm64/",
+				"linux/aarch64/",
+				"linux/arm64/",
+				"windows/ARM64/",
+				"darwin/arm64/",
+				"dragonfly/aarch64/",
+			},
+		}
+		for _, test := range tests {
+			require.Equal(t, test[1:], slices.Collect(table.Expand(test[0])), test[0])
+		}
+	})
+	t.Run("Match", func(t *testing.T) {
+		m := arks.PlatformMap{
+			"linux/_32/":     "linux 32",
+			"dragonfly/_64/": "dragonfly 64",
+			"darwin/arm64/":  "darwin arm64",
+		}
+
+		k, ok := table.Match(m, "linux/i686")
+		require.True(t, ok)
+		require.Equal(t, arks.Platform("linux/_32/"), k)
+
+		k, ok = table.Match(m, "dragonfly/amd64")
+		require.True(t, ok)
+		require.Equal(t, arks.Platform("dragonfly/_64/"), k)
+
+		k, ok = table.Match(m, "macos/arm64")
+		require.True(t, ok)
+		require.Equal(t, arks.Platform("darwin/arm64/"), k)
+
+		_, ok = table.Match(m, "plan9/amd64")
+		require.False(t, ok)
+	})
+	t.Run("default is not modified", func(t *testing.T) {
+		table := arks.DefaultPlatformTable()
+		table.Merge(map[arks.Os]arks.OsConfig{"linux": {Archs: map[arks.Arch][]arks.Arch{"x86": {"i686"}}}})
+		require.Equal(t, arks.DefaultPlatformTable(), table)
+	})
+}
@@ -1,11 +1,37 @@
+interface Metadata {
+	// Whether the key refers the version by its alias.
+	alias: boolean;
+	// Max age of the redirect in seconds.
+	max_age: number;
+	// Platform the requested one falls back to, if any.
+	fallback?: string;
+}
+
 export default {
 	async fetch(request, env, ctx): Promise<Response> {
 		const k = new URL(request.url).pathname.slice(1)
-		const v = await env.KV.get(k)
+		const { value: v, metadata } = await env.KV.getWithMetadata<Metadata>(k)
 		if (v === null) {
 			return new Response('Not Found', { status: 404 });
 		}
-		
-		return Response.redirect(`https://${v}`, 301);
+
+		// Values rendered before the target scheme was introduced have no scheme.
+		const target = v.includes('://') ? v : `https://${v}`;
+		if (metadata === null) {
+			return Response.redirect(target, 301);
+		}
+
+		const headers = new Headers({ Location: target });
+		if (metadata.fallback) {
+			headers.set('X-Arks-Fallback', metadata.fallback);
+		}
+		// Aliases can be moved to another version so they must not be cached permanently.
+		if (metadata.alias) {
+			headers.set('Cache-Control', `public, max-age=${metadata.max_age}`);
+			return new Response(null, { status: 307, headers });
+		}
+
+		headers.set('Cache-Control', `public, max-age=${metadata.max_age}, immutable`);
+		return new Response(null, { status: 308, headers });
 	},
 } satisfies ExportedHandler<Env>;
@@ -22,3 +22,28 @@ describe('Hello World worker', () => {
 		expect(await response.text()).toMatchInlineSnapshot(`"Hello World!"`);
 	});
 });
+
+describe('redirect', () => {
+	it('reports the fallback platform', async () => {
+		const k = 'example.com/foo@1.0/darwin/arm64';
+		await env.KV.put(k, 'https://example.com/foo-darwin-amd64', {
+			metadata: { alias: false, max_age: 60, fallback: 'darwin/amd64' },
+		});
+
+		const response = await SELF.fetch(`https://example.com/${k}`, { redirect: 'manual' });
+		expect(response.status).toBe(308);
+		expect(response.headers.get('Location')).toBe('https://example.com/foo-darwin-amd64');
+		expect(response.headers.get('X-Arks-Fallback')).toBe('darwin/amd64');
+	});
+
+	it('does not report a fallback if there is none', async () => {
+		const k = 'example.com/foo@1.0/darwin/amd64';
+		await env.KV.put(k, 'https://example.com/foo-darwin-amd64', {
+			metadata: { alias: false, max_age: 60 },
+		});
+
+		const response = await SELF.fetch(`https://example.com/${k}`, { redirect: 'manual' });
+		expect(response.status).toBe(308);
+		expect(response.headers.get('X-Arks-Fallback')).toBeNull();
+	});
+});
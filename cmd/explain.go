@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/lesomnus/xli"
+	"github.com/lesomnus/xli/arg"
+	"github.com/lesomnus/xli/flg"
+)
+
+func NewCmdExplain() *xli.Command {
+	default_port := _default_port
+	return &xli.Command{
+		Name:  "explain",
+		Brief: "Trace how an item is resolved",
+
+		Flags: flg.Flags{
+			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+		},
+		Args: arg.Args{
+			&arg.String{Name: "ITEM", Brief: "Item or URL to explain, e.g. /lesomnus/arrakis/arks@latest/linux/x86_64"},
+		},
+
+		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
+			port_path := flg.MustGet[string](cmd, "port")
+			s := arg.MustGet[string](cmd, "ITEM")
+
+			item, err := parseQuery(s)
+			if err != nil {
+				return err
+			}
+
+			port, err := os.OpenRoot(port_path)
+			if err != nil {
+				return fmt.Errorf("open port: %w", err)
+			}
+			defer port.Close()
+
+			x, err := arks.Explain(port.FS().(fs.ReadDirFS), item)
+
+			w := tabwriter.NewWriter(cmd, 0, 4, 2, ' ', 0)
+			printExplanation(w, s, x)
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("flush: %w", err)
+			}
+			if err != nil {
+				return err
+			}
+
+			return next(ctx)
+		}),
+	}
+}
+
+func printExplanation(w *tabwriter.Writer, s string, x arks.Explanation) {
+	fmt.Fprintf(w, "request\t%s\n", s)
+	if len(x.Configs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nconfig\tpath\ttarget path\ttarget suffix\n")
+	for _, step := range x.Configs {
+		c := step.Config
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", step.Dir, c.Path, c.Target.Path, c.Target.Suffix)
+	}
+	c := x.Config
+	fmt.Fprintf(w, "  (app %s)\t%s\t%s\t%s\n", x.App.Name, c.Path, c.Target.Path, c.Target.Suffix)
+
+	fmt.Fprintf(w, "\nplatform\t%s\n", x.Item.Platform)
+	if x.Result.Fallback == "" {
+		printScores(w, x, x.Scores, x.Result.Pattern)
+	} else {
+		printScores(w, x, x.Scores, "")
+		fmt.Fprintf(w, "\nfallback\t%s\n", x.Result.Fallback)
+		printScores(w, x, x.FallbackScores, x.Result.Pattern)
+	}
+
+	fmt.Fprintf(w, "\nversion\t%s\n", x.Item.Version)
+	if x.Version != "" {
+		fmt.Fprintf(w, "  line\t%s\n", strings.Join(slices.Collect(x.Version.Values()), " "))
+		fmt.Fprintf(w, "  value\t%s\n", x.Version.Value())
+		fmt.Fprintf(w, "  alias\t%t\n", x.Item.Version.String() != x.Version.Value())
+	}
+	if x.Result.Target == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "\ntemplate\t%s\n", x.App.Path)
+	fmt.Fprintf(w, "  output\t%s\n", x.Output)
+	fmt.Fprintf(w, "  target\t%s\n", x.Result.Target)
+	if x.Result.Checksum != "" {
+		fmt.Fprintf(w, "  checksum\t%s\n", x.Result.Checksum)
+	}
+}
+
+// printScores prints the given scores of the platform patterns in descending order
+// marking the given pattern as the one taken.
+func printScores(w *tabwriter.Writer, x arks.Explanation, scores map[arks.Platform]int, taken arks.Platform) {
+	patterns := slices.SortedFunc(maps.Keys(scores), func(a, b arks.Platform) int {
+		if d := cmp.Compare(scores[b], scores[a]); d != 0 {
+			return d
+		}
+		return cmp.Compare(a, b)
+	})
+	for _, pattern := range patterns {
+		mark := ""
+		if taken != "" && pattern == taken {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "  %s%s\t%d\t-> %s\n", mark, pattern, scores[pattern], x.App.Platforms[pattern])
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/lesomnus/xli"
+	"github.com/lesomnus/xli/flg"
+)
+
+func NewCmdLint() *xli.Command {
+	default_port := _default_port
+	return &xli.Command{
+		Name:  "lint",
+		Brief: "Validate files of the port strictly",
+
+		Flags: flg.Flags{
+			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+			&flg.Switch{Name: "json", Brief: "Print diagnostics as JSON lines"},
+		},
+
+		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
+			port_path := flg.MustGet[string](cmd, "port")
+			with_json := false
+			flg.VisitP(cmd, "json", &with_json)
+
+			port, err := os.OpenRoot(port_path)
+			if err != nil {
+				return fmt.Errorf("open port: %w", err)
+			}
+			defer port.Close()
+
+			ds, err := arks.Lint(port.FS().(fs.ReadDirFS))
+			if err != nil {
+				return fmt.Errorf("lint port: %w", err)
+			}
+
+			enc := json.NewEncoder(cmd)
+			for _, d := range ds {
+				d = d.In(port_path)
+				if with_json {
+					if err := enc.Encode(d); err != nil {
+						return err
+					}
+					continue
+				}
+				cmd.Println(d.String())
+			}
+			if len(ds) > 0 {
+				return fmt.Errorf("%d problems found", len(ds))
+			}
+
+			return next(ctx)
+		}),
+	}
+}
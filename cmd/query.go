@@ -1,39 +1,146 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/lesomnus/arrakis/arks"
 	"github.com/lesomnus/xli"
+	"github.com/lesomnus/xli/arg"
+	"github.com/lesomnus/xli/flg"
 )
 
 func NewCmdQuery() *xli.Command {
+	default_port := _default_port
 	return &xli.Command{
-		Name: "query",
+		Name:  "query",
+		Brief: "Resolve items into their targets",
+		Synop: "Items are read from stdin line by line if none are given.",
 
-		// Args: arg.Args{
-		// 	&arg.String{Name: "PATH"},
-		// 	&arg.String{Name: "NAME"},
-		// },
+		Flags: flg.Flags{
+			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+		},
+		Args: arg.Args{
+			&arg.RestStrings{Name: "ITEM", Brief: "Item or URL to resolve, e.g. /lesomnus/arrakis/arks@latest/linux/x86_64"},
+		},
 
 		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
-			port := os.DirFS("./port")
-			item := arks.Item{
-				Path:     "github.com/protocolbuffers/protobuf/protoc",
-				Version:  "33.4",
-				Platform: arks.Platform("linux/arm64/"),
+			port_path := flg.MustGet[string](cmd, "port")
+			queries, _ := arg.Get[[]string](cmd, "ITEM")
+
+			port, err := os.OpenRoot(port_path)
+			if err != nil {
+				return fmt.Errorf("open port: %w", err)
 			}
+			defer port.Close()
 
-			q := arks.FsQuerier{FS: port}
-			target, err := q.Query(ctx, item)
+			q, err := arks.NewIndexQuerier(port.FS().(fs.ReadDirFS))
 			if err != nil {
-				return err
+				return fmt.Errorf("index port: %w", err)
+			}
+
+			cnt := 0
+			enc := json.NewEncoder(cmd)
+			query := func(s string) error {
+				v := query(ctx, q, s)
+				if v.Error != "" {
+					cnt++
+				}
+				return enc.Encode(v)
 			}
 
-			cmd.Println(target)
+			if len(queries) > 0 {
+				for _, s := range queries {
+					if err := query(s); err != nil {
+						return err
+					}
+				}
+			} else {
+				s := bufio.NewScanner(cmd.ReadCloser)
+				for s.Scan() {
+					l := strings.TrimSpace(s.Text())
+					if l == "" || l[0] == '#' {
+						continue
+					}
+					if err := query(l); err != nil {
+						return err
+					}
+				}
+				if err := s.Err(); err != nil {
+					return fmt.Errorf("read stdin: %w", err)
+				}
+			}
+			if cnt > 0 {
+				return fmt.Errorf("%d items not resolved", cnt)
+			}
 
 			return next(ctx)
 		}),
 	}
 }
+
+type queryResult struct {
+	Query string `json:"query"`
+
+	Origin   string        `json:"origin,omitempty"`
+	Version  string        `json:"version,omitempty"`
+	Alias    bool          `json:"alias,omitempty"`
+	Pattern  arks.Platform `json:"pattern,omitempty"`
+	Platform arks.Platform `json:"platform,omitempty"`
+	Target   string        `json:"target,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// query resolves the given item or URL of an item.
+func query(ctx context.Context, q arks.Querier, s string) queryResult {
+	v := queryResult{Query: s}
+
+	item, err := parseQuery(s)
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
+
+	res, err := q.Query(ctx, item)
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
+
+	v.Origin = res.Origin
+	v.Version = res.Version.Value()
+	v.Alias = res.Alias
+	v.Pattern = res.Pattern
+	v.Platform = res.Platform
+	v.Target = res.Target
+	return v
+}
+
+// parseQuery parses the given item or URL of an item.
+func parseQuery(s string) (arks.Item, error) {
+	p := s
+	if strings.Contains(p, "://") {
+		u, err := url.Parse(p)
+		if err != nil {
+			return arks.Item{}, fmt.Errorf("parse URL: %w", err)
+		}
+		p = u.Path
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	item, err := arks.ParseItem(p)
+	if err != nil {
+		return arks.Item{}, fmt.Errorf("parse item: %w", err)
+	}
+
+	return item, nil
+}
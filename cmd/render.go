@@ -21,7 +21,7 @@ func NewCmdRender() *xli.Command {
 
 		Flags: flg.Flags{
 			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
-			&flg.String{Name: "kind", Value: &default_renderer, Brief: "Output kind (tree, cfkv)"},
+			&flg.String{Name: "kind", Value: &default_renderer, Brief: "Output kind (tree, cfkv, install, index)"},
 			&flg.Switch{Name: "diff", Brief: "Render only differences with the snapshot"},
 		},
 
@@ -35,6 +35,10 @@ func NewCmdRender() *xli.Command {
 			if !ok {
 				return fmt.Errorf("unknown renderer kind: %q", renderer_kind)
 			}
+			if with_diff && slices.Contains([]string{"install", "index"}, renderer_kind) {
+				// Scripts and listings cover every origin so they cannot be rendered partially.
+				return fmt.Errorf("renderer kind %q does not support diff", renderer_kind)
+			}
 
 			r := rc(os.Stdout)
 			if info, err := os.Stat(port_path); err != nil {
@@ -49,8 +53,7 @@ func NewCmdRender() *xli.Command {
 			}
 
 			c := arks.NewConfig()
-			defer r.Flush()
-			return arks.FsWalker{Fs: port.FS().(fs.ReadDirFS)}.Walk(c, ".", func(c arks.Config, p string, app arks.App) error {
+			err = arks.FsWalker{Fs: port.FS().(fs.ReadDirFS)}.Walk(c, ".", func(c arks.Config, p string, app arks.App) error {
 				snapshot := Snapshot{}
 				if with_diff {
 					if f, err := port.Open(filepath.Join(p, "snapshot")); err != nil {
@@ -85,11 +88,21 @@ func NewCmdRender() *xli.Command {
 						if slices.Contains(entry, item.Origin) {
 							continue
 						}
-						r.Render(c, item)
+						if err := r.Render(c, item); err != nil {
+							return fmt.Errorf("render: %w", err)
+						}
 					}
 				}
 				return nil
 			})
+			if err != nil {
+				return err
+			}
+			if err := r.Flush(); err != nil {
+				return fmt.Errorf("flush: %w", err)
+			}
+
+			return next(ctx)
 		}),
 	}
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/lesomnus/xli"
+	"github.com/lesomnus/xli/arg"
+	"github.com/lesomnus/xli/flg"
+)
+
+func NewCmdReverse() *xli.Command {
+	default_port := _default_port
+	return &xli.Command{
+		Name:  "reverse",
+		Brief: "List origins pointing to a target",
+		Synop: "Every origin whose target starts with the given target is listed, grouped by app and version.",
+
+		Flags: flg.Flags{
+			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+		},
+		Args: arg.Args{
+			&arg.String{Name: "TARGET", Brief: "Target URL or its prefix, e.g. https://github.com/lesomnus/arrakis/releases/download/v0.0.1/"},
+		},
+
+		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
+			port_path := flg.MustGet[string](cmd, "port")
+			target := arg.MustGet[string](cmd, "TARGET")
+			target = trimScheme(target)
+
+			port, err := os.OpenRoot(port_path)
+			if err != nil {
+				return fmt.Errorf("open port: %w", err)
+			}
+			defer port.Close()
+
+			// App <- Version <- Target <- []Origin
+			vs := map[string]map[string]map[string][]string{}
+			cnt := 0
+
+			c := arks.NewConfig()
+			err = arks.FsWalker{Fs: port.FS().(fs.ReadDirFS)}.Walk(c, ".", func(c arks.Config, p string, app arks.App) error {
+				build, err := c.Build(app)
+				if err != nil {
+					return fmt.Errorf("prepare build for app: %w", err)
+				}
+				for items, err := range build {
+					if err != nil {
+						return fmt.Errorf("build app: %w", err)
+					}
+
+					for _, item := range items {
+						if !strings.HasPrefix(trimScheme(item.Target), target) {
+							continue
+						}
+
+						name := strings.TrimLeft(item.Path, "/") + "/" + item.Name
+						if vs[name] == nil {
+							vs[name] = map[string]map[string][]string{}
+						}
+						version := item.Version.Value()
+						if vs[name][version] == nil {
+							vs[name][version] = map[string][]string{}
+						}
+						vs[name][version][item.Target] = append(vs[name][version][item.Target], item.Origin)
+						cnt++
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("walk port: %w", err)
+			}
+			if cnt == 0 {
+				return fmt.Errorf("no origins point to %q", target)
+			}
+
+			for _, name := range slices.Sorted(maps.Keys(vs)) {
+				cmd.Println(name)
+				for _, version := range slices.Sorted(maps.Keys(vs[name])) {
+					cmd.Printf("\t%s\n", version)
+					targets := vs[name][version]
+					for _, target := range slices.Sorted(maps.Keys(targets)) {
+						cmd.Printf("\t\t%s\n", target)
+						for _, origin := range slices.Sorted(slices.Values(targets[target])) {
+							cmd.Printf("\t\t\t%s\n", origin)
+						}
+					}
+				}
+			}
+
+			return next(ctx)
+		}),
+	}
+}
+
+// trimScheme removes the scheme of the given URL if it has one.
+func trimScheme(s string) string {
+	if _, v, ok := strings.Cut(s, "://"); ok {
+		return v
+	}
+	return s
+}
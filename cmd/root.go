@@ -19,9 +19,13 @@ func NewCmdRoot() *xli.Command {
 			NewCmdVersion(),
 			NewCmdRender(),
 			NewCmdQuery(),
+			NewCmdExplain(),
+			NewCmdReverse(),
 			NewCmdCommit(),
 			NewCmdDiff(),
 			NewCmdTest(),
+			NewCmdLint(),
+			NewCmdServe(),
 		},
 		Handler: xli.Chain(
 			xli.RequireSubcommand(),
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lesomnus/arrakis/arks"
+	"github.com/lesomnus/xli"
+	"github.com/lesomnus/xli/flg"
+)
+
+func NewCmdServe() *xli.Command {
+	default_port := _default_port
+	default_listen := ":8080"
+	default_prefix := "/"
+	default_watch := 5
+	default_allow_origin := ""
+	return &xli.Command{
+		Name:  "serve",
+		Brief: "Serve the resolver over HTTP",
+
+		Flags: flg.Flags{
+			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+			&flg.String{Name: "listen", Value: &default_listen, Brief: "Address to listen on"},
+			&flg.String{Name: "prefix", Value: &default_prefix, Brief: "URL path prefix to serve under"},
+			&flg.String{Name: "allow-origin", Value: &default_allow_origin, Brief: "Comma separated origins allowed for CORS (\"*\" for any)"},
+			&flg.Int{Name: "watch", Value: &default_watch, Brief: "Interval in seconds to check the port for changes (0 to disable)"},
+		},
+
+		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
+			port_path := flg.MustGet[string](cmd, "port")
+			listen := flg.MustGet[string](cmd, "listen")
+			prefix := flg.MustGet[string](cmd, "prefix")
+			watch := flg.MustGet[int](cmd, "watch")
+			allow_origins := []string{}
+			for v := range strings.SplitSeq(flg.MustGet[string](cmd, "allow-origin"), ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					allow_origins = append(allow_origins, v)
+				}
+			}
+
+			if info, err := os.Stat(port_path); err != nil {
+				return fmt.Errorf("access port path %q: %w", port_path, err)
+			} else if !info.IsDir() {
+				return fmt.Errorf("port path %q is not a directory", port_path)
+			}
+
+			port, err := os.OpenRoot(port_path)
+			if err != nil {
+				return fmt.Errorf("open port: %w", err)
+			}
+			defer port.Close()
+
+			q, err := arks.NewReloadQuerier(port.FS().(fs.ReadDirFS))
+			if err != nil {
+				return fmt.Errorf("index port: %w", err)
+			}
+			cmd.Printf("%d origins indexed\n", q.Index().Len())
+
+			ctx_sig, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			go reloadOnChange(ctx_sig, cmd, q, time.Duration(watch)*time.Second)
+
+			l, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("listen: %w", err)
+			}
+
+			s := &http.Server{
+				Handler: &arks.ServerConfig{
+					Querier: q,
+					Prefix:  prefix,
+
+					AllowOrigins: allow_origins,
+				},
+				BaseContext: func(net.Listener) context.Context { return ctx },
+			}
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- s.Serve(l)
+			}()
+			cmd.Printf("listening on %s\n", l.Addr())
+
+			select {
+			case err := <-errs:
+				return fmt.Errorf("serve: %w", err)
+			case <-ctx_sig.Done():
+			}
+
+			ctx_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := s.Shutdown(ctx_); err != nil {
+				return fmt.Errorf("shutdown: %w", err)
+			}
+			if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("serve: %w", err)
+			}
+
+			return next(ctx)
+		}),
+	}
+}
+
+// reloadOnChange rebuilds the index when the port changes or SIGHUP is received
+// until the context is done.
+func reloadOnChange(ctx context.Context, cmd *xli.Command, q *arks.ReloadQuerier, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		force := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			force = true
+		case <-tick:
+		}
+
+		ok, err := q.Reload(force)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrWriter, "reload failed, keep serving the last index: %s\n", err)
+			continue
+		}
+		if ok {
+			cmd.Printf("%d origins indexed\n", q.Index().Len())
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/lesomnus/arrakis/arks"
 	"github.com/lesomnus/xli"
@@ -20,21 +21,48 @@ func NewCmdTest() *xli.Command {
 
 		Flags: flg.Flags{
 			&flg.String{Name: "port", Value: &default_port, Brief: "Path to the port directory"},
+			&flg.Switch{Name: "roundtrip", Brief: "Test if querying each rendered origin resolves the same target"},
 		},
 
 		Handler: xli.OnRun(func(ctx context.Context, cmd *xli.Command, next xli.Next) error {
 			port_path := flg.MustGet[string](cmd, "port")
+			with_roundtrip := false
+			flg.VisitP(cmd, "roundtrip", &with_roundtrip)
 
 			port, err := os.OpenRoot(port_path)
 			if err != nil {
 				return fmt.Errorf("open port: %w", err)
 			}
 
+			var q arks.Querier = arks.FsQuerier{FS: port.FS()}
+
 			vs := map[[sha256.Size]byte]string{}
 			cnt := 0
 
 			c := arks.NewConfig()
 			err = arks.FsWalker{Fs: port.FS().(fs.ReadDirFS)}.Walk(c, ".", func(c arks.Config, p string, app arks.App) error {
+				for _, conflict := range app.Conflicts(c.Table) {
+					cmd.Println(p)
+					cmd.Printf("\t%s\n", conflict)
+					cnt++
+				}
+
+				tests, err := arks.ReadAppTestsFromFs(port.FS(), p)
+				if err != nil {
+					return fmt.Errorf("read app tests: %w", err)
+				}
+				if len(tests) > 0 {
+					failures, err := arks.RunAppTests(ctx, q, c, app, tests)
+					if err != nil {
+						return fmt.Errorf("run app tests: %w", err)
+					}
+					for _, failure := range failures {
+						cmd.Println(filepath.Join(p, "app_test.yaml"))
+						cmd.Printf("\t%s\n", failure)
+						cnt++
+					}
+				}
+
 				build, err := c.Build(app)
 				if err != nil {
 					return fmt.Errorf("prepare build for app: %w", err)
@@ -57,6 +85,15 @@ func NewCmdTest() *xli.Command {
 						}
 
 						vs[v] = p
+
+						if !with_roundtrip {
+							continue
+						}
+						if msg := roundtrip(ctx, q, item); msg != "" {
+							cmd.Println(item.Origin)
+							cmd.Printf("\t%s\n", msg)
+							cnt++
+						}
 					}
 				}
 
@@ -66,9 +103,29 @@ func NewCmdTest() *xli.Command {
 				return fmt.Errorf("walk port: %w", err)
 			}
 			if cnt > 0 {
-				return fmt.Errorf("%d conflicts found", cnt)
+				return fmt.Errorf("%d problems found", cnt)
 			}
 			return next(ctx)
 		}),
 	}
 }
+
+// roundtrip queries the origin of the given rendered item and
+// describes how the result differs from the item.
+// It returns an empty string if the result resolves the same target.
+func roundtrip(ctx context.Context, q arks.Querier, item arks.Item) string {
+	v, err := arks.ParseItem("/" + item.Origin)
+	if err != nil {
+		return fmt.Sprintf("parse origin: %s", err)
+	}
+
+	res, err := q.Query(ctx, v)
+	if err != nil {
+		return fmt.Sprintf("expected %s but got error: %s", item.Target, err)
+	}
+	if res.Target != item.Target {
+		return fmt.Sprintf("expected %s but got %s", item.Target, res.Target)
+	}
+
+	return ""
+}
@@ -11,7 +11,7 @@ import (
 func main() {
 	c := cmd.NewCmdRoot()
 	if err := c.Run(context.Background(), os.Args[1:]); err != nil {
-		fmt.Println("app exited with error:", err)
+		fmt.Fprintln(os.Stderr, "app exited with error:", err)
 		os.Exit(1)
 	}
 }